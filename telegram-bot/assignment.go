@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// assigneeFallbackAfter — через сколько без ответа сообщения по назначенному тикету снова получают все менеджеры
+// (ASSIGNEE_FALLBACK_MINUTES, по умолчанию 60; 0 — не рассылать всем)
+func assigneeFallbackAfter() time.Duration {
+	return time.Duration(envInt("ASSIGNEE_FALLBACK_MINUTES", 60)) * time.Minute
+}
+
+// unansweredSince возвращает время первого сообщения клиента после последнего ответа менеджера
+// (нулевое время, если клиент ждать ответа не должен)
+func unansweredSince(ticket *Ticket) time.Time {
+	var since time.Time
+	for _, m := range ticket.Messages {
+		if m.IsFromManager {
+			since = time.Time{}
+		} else if since.IsZero() {
+			since = m.Time
+		}
+	}
+	return since
+}
+
+// ticketNotifyRecipients возвращает менеджеров, которым уходят уведомления по тикету:
+// ответственному, если тикет взят, иначе — всем. fallback=true, если ответственный
+// не ответил дольше assigneeFallbackAfter и уведомление снова получают все.
+// В режиме группы поддержки общий поток идет в тему тикета, и рассылка всем в личные чаты не нужна.
+func ticketNotifyRecipients(ticket *Ticket) ([]int64, bool) {
+	broadcast := getManagerIDs()
+	if supportGroupEnabled() {
+		broadcast = nil
+	}
+	if ticket.AssigneeID == 0 || !isManagerID(ticket.AssigneeID) {
+		return broadcast, false
+	}
+	if fallback := assigneeFallbackAfter(); fallback > 0 {
+		if since := unansweredSince(ticket); !since.IsZero() && time.Since(since) >= fallback {
+			return broadcast, true
+		}
+	}
+	return []int64{ticket.AssigneeID}, false
+}
+
+// setTicketAssignee назначает ответственного (0 — снять назначение)
+func setTicketAssignee(ticket *Ticket, managerID int64) {
+	ticket.AssigneeID = managerID
+	ticket.AssigneeName = ""
+	ticket.AssignedAt = time.Time{}
+	if managerID != 0 {
+		ticket.AssigneeName = managerDisplayName(managerID)
+		ticket.AssignedAt = time.Now()
+	}
+}
+
+// assigneeText возвращает ответственного за тикет для отображения
+func assigneeText(ticket *Ticket) string {
+	if ticket.AssigneeID == 0 {
+		return "не назначен"
+	}
+	return managerDisplayName(ticket.AssigneeID)
+}
+
+// notifyManagersExcept отправляет сообщение всем менеджерам, кроме указанного
+func notifyManagersExcept(bot *tgbotapi.BotAPI, exceptID int64, text string) {
+	for _, mid := range getManagerIDs() {
+		if mid == exceptID {
+			continue
+		}
+		bot.Send(tgbotapi.NewMessage(mid, text))
+	}
+}
+
+// claimTicket закрепляет тикет за менеджером, нажавшим «🙋 Взять»
+func claimTicket(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	if ticket.Status != "open" {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет закрыт"))
+		return
+	}
+	if ticket.AssigneeID == chatID {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("ℹ️ Тикет #%d уже у вас", ticketID)))
+		return
+	}
+	if ticket.AssigneeID != 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Тикет #%d уже взял %s", ticketID, assigneeText(ticket))))
+		return
+	}
+
+	setTicketAssignee(ticket, chatID)
+	logTicketEvent(ticket, "claimed", chatID, fmt.Sprintf("%s взял тикет", managerDisplayName(chatID)))
+	saveTickets()
+
+	notifyManagersExcept(bot, chatID, fmt.Sprintf("🙋 %s взял тикет #%d", managerDisplayName(chatID), ticketID))
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Тикет #%d закреплен за вами. Новые сообщения клиента будут приходить только вам.", ticketID)))
+
+	log.Printf("Тикет #%d взят менеджером %d", ticketID, chatID)
+	showTicketDetails(bot, chatID, ticketID)
+}
+
+// releaseTicket возвращает тикет в общую очередь
+func releaseTicket(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	if ticket.AssigneeID != chatID {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет закреплен не за вами"))
+		return
+	}
+
+	setTicketAssignee(ticket, 0)
+	logTicketEvent(ticket, "released", chatID, fmt.Sprintf("%s вернул тикет в общую очередь", managerDisplayName(chatID)))
+	saveTickets()
+
+	notifyManagersExcept(bot, chatID, fmt.Sprintf("↩️ %s вернул тикет #%d в общую очередь", managerDisplayName(chatID), ticketID))
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Тикет #%d возвращен в общую очередь", ticketID)))
+
+	log.Printf("Тикет #%d возвращен в очередь менеджером %d", ticketID, chatID)
+}
+
+// ticketCardKeyboard — кнопки под карточкой нового тикета
+func ticketCardKeyboard(ticket *Ticket) tgbotapi.InlineKeyboardMarkup {
+	row := []tgbotapi.InlineKeyboardButton{}
+	if ticket.AssigneeID == 0 {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData("🙋 Взять", fmt.Sprintf("ticket_claim_%d", ticket.ID)))
+	}
+	row = append(row, tgbotapi.NewInlineKeyboardButtonData("👁 Открыть", fmt.Sprintf("ticket_view_%d", ticket.ID)))
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// unansweredPrefix — пометка для уведомления, разосланного всем из-за долгого отсутствия ответа
+func unansweredPrefix(ticket *Ticket) string {
+	return fmt.Sprintf("⚠️ Ответственный (%s) не отвечает более %d мин\n\n",
+		assigneeText(ticket), int(assigneeFallbackAfter().Minutes()))
+}
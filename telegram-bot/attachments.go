@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Attachment — вложение сообщения в тикете (хранится file_id Telegram)
+type Attachment struct {
+	Type     string `json:"type"` // "photo", "document", "voice", "video", "sticker"
+	FileID   string `json:"file_id"`
+	FileName string `json:"file_name,omitempty"`
+}
+
+// messageContent возвращает текст сообщения или подпись к медиа
+func messageContent(message *tgbotapi.Message) string {
+	if message.Text != "" {
+		return message.Text
+	}
+	return message.Caption
+}
+
+// messageAttachments извлекает поддерживаемые вложения из сообщения Telegram
+func messageAttachments(message *tgbotapi.Message) []Attachment {
+	var atts []Attachment
+	if len(message.Photo) > 0 {
+		// Берем самый крупный вариант фото
+		atts = append(atts, Attachment{Type: "photo", FileID: message.Photo[len(message.Photo)-1].FileID})
+	}
+	if message.Document != nil {
+		atts = append(atts, Attachment{Type: "document", FileID: message.Document.FileID, FileName: message.Document.FileName})
+	}
+	if message.Voice != nil {
+		atts = append(atts, Attachment{Type: "voice", FileID: message.Voice.FileID})
+	}
+	if message.Video != nil {
+		atts = append(atts, Attachment{Type: "video", FileID: message.Video.FileID, FileName: message.Video.FileName})
+	}
+	if message.Sticker != nil {
+		atts = append(atts, Attachment{Type: "sticker", FileID: message.Sticker.FileID, FileName: message.Sticker.Emoji})
+	}
+	return atts
+}
+
+// hasTicketContent проверяет, есть ли в сообщении что сохранить в тикет
+func hasTicketContent(message *tgbotapi.Message) bool {
+	return strings.TrimSpace(messageContent(message)) != "" || len(messageAttachments(message)) > 0
+}
+
+// unsupportedContentText — ответ на сообщение без текста и поддерживаемых вложений
+const unsupportedContentText = "⚠️ Этот тип сообщения не поддерживается. Отправьте текст, фото, документ, голосовое, видео или стикер."
+
+// attachmentLabel возвращает подпись вложения для отображения в диалоге
+func attachmentLabel(a Attachment) string {
+	var label string
+	switch a.Type {
+	case "photo":
+		label = "📷 Фото"
+	case "document":
+		label = "📎 Документ"
+	case "voice":
+		label = "🎤 Голосовое"
+	case "video":
+		label = "🎬 Видео"
+	case "sticker":
+		label = "🙂 Стикер"
+	default:
+		label = "📎 Вложение"
+	}
+	if a.FileName != "" {
+		label += ": " + a.FileName
+	}
+	return label
+}
+
+// attachmentsText возвращает отметки вложений вида "[📷 Фото] [🎤 Голосовое]"
+func attachmentsText(atts []Attachment) string {
+	parts := make([]string, 0, len(atts))
+	for _, a := range atts {
+		parts = append(parts, "["+attachmentLabel(a)+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// messageDisplayText возвращает текст сообщения тикета с отметками вложений
+func messageDisplayText(msg Message) string {
+	if !msg.RecalledAt.IsZero() {
+		return "🗑 Сообщение отозвано"
+	}
+	text := msg.Text
+	if len(msg.Attachments) > 0 {
+		text = attachmentsText(msg.Attachments)
+		if msg.Text != "" {
+			text += "\n" + msg.Text
+		}
+	}
+	return text + editedMark(msg)
+}
+
+// attachmentExportText возвращает вложения для выгрузки: "photo:<file_id>; document:<имя>:<file_id>"
+func attachmentExportText(atts []Attachment) string {
+	parts := make([]string, 0, len(atts))
+	for _, a := range atts {
+		if a.FileName != "" {
+			parts = append(parts, fmt.Sprintf("%s:%s:%s", a.Type, a.FileName, a.FileID))
+		} else {
+			parts = append(parts, fmt.Sprintf("%s:%s", a.Type, a.FileID))
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// attachmentChattable формирует сообщение Telegram для повторной отправки вложения по file_id
+func attachmentChattable(chatID int64, a Attachment, caption string) tgbotapi.Chattable {
+	file := tgbotapi.FileID(a.FileID)
+	switch a.Type {
+	case "photo":
+		m := tgbotapi.NewPhoto(chatID, file)
+		m.Caption = caption
+		return m
+	case "voice":
+		m := tgbotapi.NewVoice(chatID, file)
+		m.Caption = caption
+		return m
+	case "video":
+		m := tgbotapi.NewVideo(chatID, file)
+		m.Caption = caption
+		return m
+	case "sticker":
+		return tgbotapi.NewSticker(chatID, file)
+	default:
+		m := tgbotapi.NewDocument(chatID, file)
+		m.Caption = caption
+		return m
+	}
+}
+
+// sendAttachments пересылает вложения в чат (подпись добавляется к каждому) и возвращает отправленные сообщения
+func sendAttachments(bot *tgbotapi.BotAPI, chatID int64, atts []Attachment, caption string) []tgbotapi.Message {
+	var sent []tgbotapi.Message
+	for _, a := range atts {
+		if m, err := bot.Send(attachmentChattable(chatID, a, caption)); err == nil {
+			sent = append(sent, m)
+		}
+	}
+	return sent
+}
+
+// showTicketAttachments отправляет менеджеру все вложения тикета
+func showTicketAttachments(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+
+	count := 0
+	for _, m := range ticket.Messages {
+		sender := "👤 Клиент"
+		if m.IsFromManager {
+			sender = "👨‍💼 Менеджер"
+		}
+		caption := fmt.Sprintf("#%d %s (%s)", m.ID, sender, m.Time.Format("02.01 15:04"))
+		sendAttachments(bot, chatID, m.Attachments, caption)
+		count += len(m.Attachments)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("📎 Вложений в тикете #%d: %d", ticketID, count))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔙 К тикету", fmt.Sprintf("ticket_view_%d", ticketID)),
+		),
+	)
+	bot.Send(msg)
+}
+
+// countTicketAttachments считает вложения во всех сообщениях тикета
+func countTicketAttachments(ticket *Ticket) int {
+	count := 0
+	for _, m := range ticket.Messages {
+		count += len(m.Attachments)
+	}
+	return count
+}
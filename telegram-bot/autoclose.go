@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const autoCloseCheckInterval = 15 * time.Minute
+
+// autoCloseWarnAfter — неактивность, после которой клиент получает предупреждение (AUTO_CLOSE_WARN_DAYS, 0 — отключено)
+func autoCloseWarnAfter() time.Duration {
+	return time.Duration(envInt("AUTO_CLOSE_WARN_DAYS", 3)) * 24 * time.Hour
+}
+
+// autoCloseAfter — сколько ждать после предупреждения до закрытия (AUTO_CLOSE_DAYS)
+func autoCloseAfter() time.Duration {
+	return time.Duration(envInt("AUTO_CLOSE_DAYS", 4)) * 24 * time.Hour
+}
+
+// startAutoCloseScheduler запускает фоновое закрытие неактивных тикетов
+func startAutoCloseScheduler(bot *tgbotapi.BotAPI) {
+	go func() {
+		if autoCloseWarnAfter() == 0 {
+			log.Printf("Автозакрытие тикетов отключено (AUTO_CLOSE_WARN_DAYS=0)")
+			return
+		}
+		log.Printf("🗄 Запущено автозакрытие тикетов: предупреждение через %v, закрытие еще через %v",
+			autoCloseWarnAfter(), autoCloseAfter())
+
+		ticker := time.NewTicker(autoCloseCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			stateMu.Lock()
+			out := checkInactiveTickets()
+			stateMu.Unlock()
+			deliverOutbox(bot, out)
+		}
+	}()
+}
+
+// checkInactiveTickets закрывает просроченные тикеты и возвращает предупреждения клиентам
+// о неактивных тикетах вместе с уведомлениями о закрытии
+func checkInactiveTickets() []outMessage {
+	warnAfter, closeAfter := autoCloseWarnAfter(), autoCloseAfter()
+	now := time.Now()
+	changed := false
+	var out []outMessage
+
+	for _, ticket := range tickets {
+		if ticket.Status != "open" {
+			continue
+		}
+		// Предупреждение, отправленное до последней активности, больше не действует
+		if !ticket.InactivityWarnedAt.IsZero() && ticket.LastMessage.After(ticket.InactivityWarnedAt) {
+			ticket.InactivityWarnedAt = time.Time{}
+			changed = true
+		}
+
+		if ticket.InactivityWarnedAt.IsZero() {
+			if now.Sub(ticket.LastMessage) >= warnAfter {
+				out = append(out, warnInactiveTicket(ticket))
+				changed = true
+			}
+		} else if now.Sub(ticket.InactivityWarnedAt) >= closeAfter {
+			out = append(out, closeTicketOutbox(ticket, 0, "auto")...)
+			log.Printf("Тикет #%d закрыт автоматически из-за неактивности", ticket.ID)
+		}
+	}
+
+	if changed {
+		saveTickets()
+	}
+	return out
+}
+
+// warnInactiveTicket готовит предупреждение клиенту о скором закрытии тикета
+func warnInactiveTicket(ticket *Ticket) outMessage {
+	ticket.InactivityWarnedAt = time.Now()
+	logTicketEvent(ticket, "inactivity_warning", 0, "Клиент предупрежден об автозакрытии")
+
+	days := int(autoCloseAfter().Hours() / 24)
+	msg := tgbotapi.NewMessage(ticket.UserID, fmt.Sprintf("💤 В диалоге по тикету #%d давно не было сообщений.\n\n"+
+		"Если вопрос еще актуален, нажмите кнопку ниже — иначе диалог будет закрыт через %d дн.", ticket.ID, days))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Вопрос ещё актуален", fmt.Sprintf("client_ticket_keep_%d", ticket.ID)),
+		),
+	)
+
+	log.Printf("Тикет #%d: клиент %d предупрежден об автозакрытии", ticket.ID, ticket.UserID)
+	return outMessage{Msg: msg, Ticket: ticket, ToClient: true}
+}
+
+// handleClientKeepTicket обрабатывает нажатие «Вопрос ещё актуален»
+func handleClientKeepTicket(bot *tgbotapi.BotAPI, chatID int64, data string) {
+	ticketID, ok := parseTicketIDSuffix("client_ticket_keep_", data)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Ошибка ID тикета"))
+		return
+	}
+	ticket, exists := tickets[ticketID]
+	if !exists || ticket.UserID != chatID {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	if ticket.Status != "open" {
+		bot.Send(tgbotapi.NewMessage(chatID, "🔒 Этот диалог уже закрыт. Если вопрос остался, создайте новый диалог."))
+		return
+	}
+
+	ticket.InactivityWarnedAt = time.Time{}
+	ticket.LastMessage = time.Now()
+	logTicketEvent(ticket, "kept_alive", chatID, "Клиент подтвердил, что вопрос актуален")
+	saveTickets()
+
+	ids, _ := ticketNotifyRecipients(ticket)
+	for _, mid := range ids {
+		msg := tgbotapi.NewMessage(mid, fmt.Sprintf("🔔 Клиент подтвердил, что вопрос по тикету #%d еще актуален", ticketID))
+		msg.ReplyMarkup = ticketCardKeyboard(ticket)
+		bot.Send(msg)
+	}
+
+	userTickets[chatID] = ticketID
+	questionStates[chatID] = true
+	bot.Send(tgbotapi.NewMessage(chatID, "✅ Спасибо! Диалог остается открытым — напишите, что еще нужно уточнить."))
+}
@@ -0,0 +1,327 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ClientProfile — сведения о клиенте, накопленные ботом вне тикетов
+type ClientProfile struct {
+	UserID       int64               `json:"user_id"`
+	Username     string              `json:"username,omitempty"`
+	FirstName    string              `json:"first_name,omitempty"`
+	LastName     string              `json:"last_name,omitempty"`
+	LanguageCode string              `json:"language_code,omitempty"`
+	FirstSeen    time.Time           `json:"first_seen"`
+	LastSeen     time.Time           `json:"last_seen"`
+	CatalogViews int                 `json:"catalog_views,omitempty"`
+	Products     []ClientProductPick `json:"products,omitempty"`     // товары, выбранные для подбора
+	Measurements []MeasurementRecord `json:"measurements,omitempty"` // история замеров и рекомендаций
+}
+
+// ClientProductPick — товар, который клиент выбрал для подбора размера
+type ClientProductPick struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+}
+
+// MeasurementRecord — результат завершенного подбора размера
+type MeasurementRecord struct {
+	Time            time.Time      `json:"time"`
+	Product         string         `json:"product"`
+	Values          map[string]int `json:"values"` // ключи measurementSteps, включая рост и обхват груди
+	Oversize        bool           `json:"oversize,omitempty"`
+	RecommendedSize string         `json:"recommended_size,omitempty"`
+}
+
+const clientsStoreFile = "clients.json"
+
+// Храним ограниченную историю, чтобы профиль не разрастался
+const (
+	maxClientProducts     = 20
+	maxClientMeasurements = 20
+)
+
+var clientProfiles = make(map[int64]*ClientProfile)
+
+// clientsDirty — в профилях есть несохраненные частые изменения (просмотры каталога, активность),
+// они записываются в файл раз в clientsSaveInterval или вместе со следующим сохранением
+var clientsDirty bool
+
+const clientsSaveInterval = 5 * time.Minute
+
+func loadClients() {
+	data, err := os.ReadFile(clientsStoreFile)
+	if err != nil {
+		log.Printf("Файл профилей клиентов не найден, начинаем с пустого списка")
+		return
+	}
+	if err := json.Unmarshal(data, &clientProfiles); err != nil {
+		log.Printf("Ошибка чтения %s: %v", clientsStoreFile, err)
+		return
+	}
+	log.Printf("Загружено %d профилей клиентов", len(clientProfiles))
+}
+
+func saveClients() {
+	data, err := json.MarshalIndent(clientProfiles, "", "  ")
+	if err != nil {
+		log.Printf("Ошибка сериализации профилей клиентов: %v", err)
+		return
+	}
+	if err := os.WriteFile(clientsStoreFile, data, 0644); err != nil {
+		log.Printf("Ошибка записи %s: %v", clientsStoreFile, err)
+		return
+	}
+	clientsDirty = false
+}
+
+// startClientsSaver периодически сохраняет накопленные изменения профилей клиентов
+func startClientsSaver() {
+	go func() {
+		ticker := time.NewTicker(clientsSaveInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			stateMu.Lock()
+			if clientsDirty {
+				saveClients()
+			}
+			stateMu.Unlock()
+		}
+	}()
+}
+
+// clientProfile возвращает профиль клиента, создавая его при необходимости
+func clientProfile(userID int64) *ClientProfile {
+	p, ok := clientProfiles[userID]
+	if !ok {
+		now := time.Now()
+		p = &ClientProfile{UserID: userID, FirstSeen: now, LastSeen: now}
+		clientProfiles[userID] = p
+	}
+	return p
+}
+
+// touchClient обновляет контактные данные и время последней активности пользователя в личном чате.
+// Файл сохраняется сразу только при изменении данных, время активности — вместе с остальными
+// частыми изменениями (startClientsSaver).
+func touchClient(user *tgbotapi.User) {
+	if user == nil || user.IsBot {
+		return
+	}
+	_, known := clientProfiles[user.ID]
+	p := clientProfile(user.ID)
+	p.LastSeen = time.Now()
+
+	changed := !known
+	update := func(field *string, value string) {
+		if value != "" && *field != value {
+			*field = value
+			changed = true
+		}
+	}
+	update(&p.Username, user.UserName)
+	update(&p.FirstName, user.FirstName)
+	update(&p.LastName, user.LastName)
+	update(&p.LanguageCode, user.LanguageCode)
+	if changed {
+		saveClients()
+	} else {
+		clientsDirty = true
+	}
+}
+
+// recordCatalogView учитывает просмотр каталога клиентом (сохраняется пакетно, см. startClientsSaver)
+func recordCatalogView(chatID int64) {
+	clientProfile(chatID).CatalogViews++
+	clientsDirty = true
+}
+
+// recordProductPick запоминает товар, выбранный клиентом для подбора
+func recordProductPick(chatID int64, name string) {
+	p := clientProfile(chatID)
+	p.Products = append(p.Products, ClientProductPick{Name: name, Time: time.Now()})
+	if len(p.Products) > maxClientProducts {
+		p.Products = p.Products[len(p.Products)-maxClientProducts:]
+	}
+	saveClients()
+}
+
+// recordClientSurvey добавляет результат подбора в историю замеров клиента
+func recordClientSurvey(chatID int64, state *UserState, oversize bool, mark string) {
+	values := extraMeasurements(state)
+	if values == nil {
+		values = make(map[string]int)
+	}
+	if state.Height > 0 {
+		values["height"] = state.Height
+	}
+	if state.ChestSize > 0 {
+		values["chest"] = state.ChestSize
+	}
+
+	p := clientProfile(chatID)
+	p.Measurements = append(p.Measurements, MeasurementRecord{
+		Time:            time.Now(),
+		Product:         surveyProductName(state),
+		Values:          values,
+		Oversize:        oversize,
+		RecommendedSize: mark,
+	})
+	if len(p.Measurements) > maxClientMeasurements {
+		p.Measurements = p.Measurements[len(p.Measurements)-maxClientMeasurements:]
+	}
+	saveClients()
+}
+
+// clientFirstSeen — дата первого обращения: из профиля или, для старых клиентов, по первому тикету
+func clientFirstSeen(userID int64, list []*Ticket) time.Time {
+	var first time.Time
+	if p, ok := clientProfiles[userID]; ok {
+		first = p.FirstSeen
+	}
+	for _, t := range list {
+		if first.IsZero() || t.CreatedAt.Before(first) {
+			first = t.CreatedAt
+		}
+	}
+	return first
+}
+
+// showClientProfile показывает менеджеру карточку клиента со всеми его тикетами и историей подбора
+func showClientProfile(bot *tgbotapi.BotAPI, chatID int64, userID int64) {
+	list := clientTicketList(userID)
+	p, hasProfile := clientProfiles[userID]
+	if !hasProfile && len(list) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Клиент не найден"))
+		return
+	}
+
+	// Контактные данные: из профиля, недостающие — из тикетов
+	var username, firstName, lastName, lang string
+	if hasProfile {
+		username, firstName, lastName, lang = p.Username, p.FirstName, p.LastName, p.LanguageCode
+	}
+	for _, t := range list {
+		if username == "" {
+			username = t.Username
+		}
+		if firstName == "" && lastName == "" {
+			firstName, lastName = t.FirstName, t.LastName
+		}
+	}
+
+	var text strings.Builder
+	name := strings.TrimSpace(firstName + " " + lastName)
+	if name == "" {
+		name = "(без имени)"
+	}
+	text.WriteString(fmt.Sprintf("👤 Профиль клиента: %s\n\n", name))
+	text.WriteString(fmt.Sprintf("🆔 ID: %d\n", userID))
+	if username != "" {
+		text.WriteString(fmt.Sprintf("💬 Telegram: @%s (https://t.me/%s)\n", username, username))
+	}
+	if lang != "" {
+		text.WriteString(fmt.Sprintf("🌐 Язык: %s\n", lang))
+	}
+	if first := clientFirstSeen(userID, list); !first.IsZero() {
+		text.WriteString(fmt.Sprintf("🕐 Впервые: %s\n", first.Format("02.01.2006")))
+	}
+	if hasProfile {
+		text.WriteString(fmt.Sprintf("👁 Последняя активность: %s\n", p.LastSeen.Format("02.01.2006 15:04")))
+	}
+
+	text.WriteString(fmt.Sprintf("\n🎫 Тикеты: %d (открытых: %d)\n", len(list), countOpenClientTickets(userID)))
+	for i, t := range list {
+		if i == maxClientTicketsInList {
+			text.WriteString(fmt.Sprintf("• … и еще %d (🔍 поиск: id:%d)\n", len(list)-i, userID))
+			break
+		}
+		line := fmt.Sprintf("• #%d %s, %s", t.ID, getStatusText(t.Status), t.CreatedAt.Format("02.01.2006"))
+		if t.Category != "" {
+			line += ", " + categoryText(t.Category)
+		}
+		text.WriteString(line + "\n")
+	}
+
+	if hasProfile && len(p.Measurements) > 0 {
+		text.WriteString("\n📏 История подбора:\n")
+		records := p.Measurements
+		if len(records) > 5 {
+			records = records[len(records)-5:]
+		}
+		for i := len(records) - 1; i >= 0; i-- {
+			r := records[i]
+			line := fmt.Sprintf("• %s — %s: %s", r.Time.Format("02.01.2006"), r.Product, formatMeasurements(r.Values))
+			if r.Oversize {
+				line += ", оверсайз"
+			}
+			if r.RecommendedSize != "" {
+				line += " → " + r.RecommendedSize
+			}
+			text.WriteString(line + "\n")
+		}
+	}
+
+	if hasProfile && (len(p.Products) > 0 || p.CatalogViews > 0) {
+		text.WriteString("\n👕 Интерес к товарам:\n")
+		if p.CatalogViews > 0 {
+			text.WriteString(fmt.Sprintf("• Просмотров каталога: %d\n", p.CatalogViews))
+		}
+		// Уникальные товары, начиная с последнего выбранного
+		seen := make(map[string]bool)
+		for i := len(p.Products) - 1; i >= 0; i-- {
+			pick := p.Products[i]
+			if seen[pick.Name] {
+				continue
+			}
+			seen[pick.Name] = true
+			text.WriteString(fmt.Sprintf("• %s (подбор %s)\n", pick.Name, pick.Time.Format("02.01.2006")))
+		}
+	}
+
+	// Длинный профиль отправляем частями, кнопки — под последней
+	const maxMessageLength = 4000
+	parts := splitMessage(text.String(), maxMessageLength)
+	for _, part := range parts[:len(parts)-1] {
+		bot.Send(tgbotapi.NewMessage(chatID, part))
+	}
+	msg := tgbotapi.NewMessage(chatID, parts[len(parts)-1])
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for i, t := range list {
+		if i == maxClientTicketsInList {
+			break
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🎫 #%d", t.ID), fmt.Sprintf("ticket_view_%d", t.ID)))
+		if len(row) == 3 {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+	}
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔙 К списку тикетов", "manager_tickets"),
+	))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	bot.Send(msg)
+}
+
+// handleClientProfileCallback открывает профиль клиента по кнопке profile_<ID>
+func handleClientProfileCallback(bot *tgbotapi.BotAPI, chatID int64, data string) {
+	userID, err := strconv.ParseInt(strings.TrimPrefix(data, "profile_"), 10, 64)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Ошибка ID клиента"))
+		return
+	}
+	showClientProfile(bot, chatID, userID)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// У клиента может быть несколько тикетов одновременно. userTickets хранит тикет,
+// выбранный клиентом сейчас: в него попадают все входящие сообщения.
+
+const maxClientTicketsInList = 10
+
+// clientTicketList возвращает тикеты клиента: сначала открытые, внутри — по последней активности
+func clientTicketList(userID int64) []*Ticket {
+	var list []*Ticket
+	for _, t := range tickets {
+		if t.UserID == userID && t.MergedInto == 0 {
+			list = append(list, t)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if (list[i].Status == "open") != (list[j].Status == "open") {
+			return list[i].Status == "open"
+		}
+		return list[i].LastMessage.After(list[j].LastMessage)
+	})
+	return list
+}
+
+// countOpenClientTickets считает открытые тикеты клиента
+func countOpenClientTickets(userID int64) int {
+	count := 0
+	for _, t := range tickets {
+		if t.UserID == userID && t.Status == "open" {
+			count++
+		}
+	}
+	return count
+}
+
+// restoreUserTickets выбирает для каждого клиента последний активный открытый тикет
+// (или последний закрытый, если открытых нет)
+func restoreUserTickets() {
+	userTickets = make(map[int64]int)
+	for id, t := range tickets {
+		current, ok := tickets[userTickets[t.UserID]]
+		if !ok || isBetterClientTicket(t, current) {
+			userTickets[t.UserID] = id
+		}
+	}
+}
+
+func isBetterClientTicket(a, b *Ticket) bool {
+	if (a.Status == "open") != (b.Status == "open") {
+		return a.Status == "open"
+	}
+	return a.LastMessage.After(b.LastMessage)
+}
+
+// selectedOpenTicket возвращает выбранный клиентом тикет, если он открыт
+func selectedOpenTicket(chatID int64) (*Ticket, bool) {
+	ticketID, exists := userTickets[chatID]
+	if !exists {
+		return nil, false
+	}
+	ticket, found := tickets[ticketID]
+	if !found || ticket.Status != "open" {
+		return nil, false
+	}
+	return ticket, true
+}
+
+// reselectClientTicket после закрытия выбранного тикета переключает клиента на другой открытый тикет
+func reselectClientTicket(ticket *Ticket) {
+	if userTickets[ticket.UserID] != ticket.ID {
+		return
+	}
+	for _, t := range clientTicketList(ticket.UserID) {
+		if t.Status == "open" {
+			userTickets[ticket.UserID] = t.ID
+			return
+		}
+	}
+}
+
+// clientTicketTitle — краткое описание тикета в списке обращений клиента
+func clientTicketTitle(ticket *Ticket) string {
+	title := fmt.Sprintf("#%d", ticket.ID)
+	if ticket.Category != "" {
+		title += " " + categoryText(ticket.Category)
+	}
+	if ticket.Status != "open" {
+		title += " (закрыт)"
+	}
+	return title
+}
+
+// showClientTicketsList показывает клиенту список его обращений для переключения между ними
+func showClientTicketsList(bot *tgbotapi.BotAPI, chatID int64) {
+	list := clientTicketList(chatID)
+	if len(list) == 0 {
+		msg := tgbotapi.NewMessage(chatID, "📂 У вас пока нет обращений")
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("➕ Новый тикет", "create_new_ticket"),
+				tgbotapi.NewInlineKeyboardButtonData("🏠 Главная", "back_to_menu"),
+			),
+		)
+		bot.Send(msg)
+		return
+	}
+	if len(list) > maxClientTicketsInList {
+		list = list[:maxClientTicketsInList]
+	}
+
+	text := "📂 Мои обращения\n\nВыберите тикет — новые сообщения будут отправляться в него."
+	if ticketID, ok := userTickets[chatID]; ok {
+		text += fmt.Sprintf("\n\nСейчас выбран тикет #%d", ticketID)
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for _, t := range list {
+		label := clientTicketTitle(t)
+		if userTickets[chatID] == t.ID {
+			label = "✅ " + label
+		}
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(label, fmt.Sprintf("client_ticket_select_%d", t.ID)),
+		))
+	}
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("➕ Новый тикет", "create_new_ticket"),
+		tgbotapi.NewInlineKeyboardButtonData("🏠 Главная", "back_to_menu"),
+	))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	bot.Send(msg)
+}
+
+// handleClientTicketSelect переключает клиента на выбранный тикет
+func handleClientTicketSelect(bot *tgbotapi.BotAPI, chatID int64, data string) {
+	ticketID, ok := parseTicketIDSuffix("client_ticket_select_", data)
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Ошибка ID тикета"))
+		return
+	}
+	ticket, exists := tickets[ticketID]
+	if !exists || ticket.UserID != chatID {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+
+	userTickets[chatID] = ticketID
+	// Сообщения клиента теперь попадают в выбранный тикет
+	messageModeStates[chatID] = false
+	if ticket.Status == "open" {
+		questionStates[chatID] = true
+	} else {
+		delete(questionStates, chatID)
+	}
+	log.Printf("Клиент %d переключился на тикет #%d", chatID, ticketID)
+
+	showClientTicketInterface(bot, chatID)
+}
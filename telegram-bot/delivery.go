@@ -0,0 +1,131 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Статусы доставки ответа менеджера клиенту (Message.Delivery)
+const (
+	deliveryDelivered = "delivered"
+	deliveryFailed    = "failed"  // ошибка Telegram, клиент может получить следующие сообщения
+	deliveryBlocked   = "blocked" // клиент заблокировал бота или удалил аккаунт
+)
+
+// isClientUnreachable проверяет, что Telegram отказал с кодом 403: бот заблокирован клиентом
+// или аккаунт удален, и повторная отправка бессмысленна
+func isClientUnreachable(err error) bool {
+	var apiErr *tgbotapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == 403
+}
+
+// sendToClient отправляет сообщение клиенту тикета. Если клиент недоступен, тикет помечается
+// недоставляемым, а менеджеры (кроме notifyExcept) получают уведомление.
+// Ответы на действия самого клиента (кнопки оценки, «Вопрос ещё актуален», комментарий к оценке)
+// отправляются обычным bot.Send: клиент только что писал боту, значит он его не блокировал.
+func sendToClient(bot *tgbotapi.BotAPI, ticket *Ticket, c tgbotapi.Chattable, notifyExcept int64) (tgbotapi.Message, error) {
+	sent, err := bot.Send(c)
+	if err != nil {
+		log.Printf("Ошибка отправки клиенту %d (тикет #%d): %v", ticket.UserID, ticket.ID, err)
+		if isClientUnreachable(err) {
+			markTicketUndeliverable(bot, ticket, err, notifyExcept)
+		}
+	}
+	return sent, err
+}
+
+// deliveryErrorText — причина недоставки для менеджера
+func deliveryErrorText(err error) string {
+	if isClientUnreachable(err) {
+		return "клиент заблокировал бота или удалил аккаунт"
+	}
+	return err.Error()
+}
+
+// markTicketUndeliverable помечает тикет: сообщения клиенту не доставляются.
+// notifyExcept — чат, который узнает об ошибке сам (менеджер или группа поддержки, откуда пришел ответ).
+func markTicketUndeliverable(bot *tgbotapi.BotAPI, ticket *Ticket, err error, notifyExcept int64) {
+	sendOutbox(bot, setTicketUndeliverable(ticket, err, notifyExcept))
+}
+
+// setTicketUndeliverable ставит отметку недоставляемости и возвращает уведомления менеджерам
+func setTicketUndeliverable(ticket *Ticket, err error, notifyExcept int64) []outMessage {
+	if ticket.Undeliverable {
+		return nil
+	}
+	ticket.Undeliverable = true
+	logTicketEvent(ticket, "undeliverable", 0, fmt.Sprintf("Сообщения клиенту не доставляются: %v", err))
+	saveTickets()
+
+	text := fmt.Sprintf("⛔ Тикет #%d: клиент заблокировал бота или удалил аккаунт — сообщения ему не доставляются.\n\n"+
+		"Если клиент снова напишет боту, отметка снимется автоматически.", ticket.ID)
+	var out []outMessage
+	ids, _ := ticketNotifyRecipients(ticket)
+	for _, mid := range ids {
+		if mid != notifyExcept {
+			out = append(out, outMessage{Msg: tgbotapi.NewMessage(mid, text), Ticket: ticket})
+		}
+	}
+	if ticket.TopicID != 0 && !isSupportGroupChat(notifyExcept) {
+		out = append(out, outMessage{TopicID: ticket.TopicID, Text: text, Ticket: ticket})
+	}
+	return out
+}
+
+// clearUndeliverable снимает отметку недоставляемости, когда клиент снова пишет боту
+// или сообщение ему доставлено
+func clearUndeliverable(ticket *Ticket) {
+	if !ticket.Undeliverable {
+		return
+	}
+	ticket.Undeliverable = false
+	logTicketEvent(ticket, "delivery_restored", 0, "Доставка сообщений клиенту восстановлена")
+	log.Printf("Тикет #%d: доставка клиенту восстановлена", ticket.ID)
+}
+
+// deliveryStatusText — статус доставки сообщения менеджера для диалога
+func deliveryStatusText(msg Message) string {
+	switch msg.Delivery {
+	case deliveryDelivered:
+		return "✅ доставлено"
+	case deliveryBlocked:
+		return "⛔ не доставлено: клиент заблокировал бота"
+	case deliveryFailed:
+		if msg.DeliveryError != "" {
+			return "⚠️ не доставлено: " + msg.DeliveryError
+		}
+		return "⚠️ не доставлено"
+	}
+	return ""
+}
+
+// undeliverableLine — предупреждение для карточки тикета
+func undeliverableLine(ticket *Ticket) string {
+	if !ticket.Undeliverable {
+		return ""
+	}
+	return "⛔ Клиент заблокировал бота — ответы не доставляются\n"
+}
+
+// setMessageDelivery сохраняет результат доставки ответа менеджера клиенту
+func setMessageDelivery(ticket *Ticket, messageID int, err error) {
+	msg := findTicketMessage(ticket, messageID)
+	if msg == nil {
+		return
+	}
+	switch {
+	case err == nil:
+		msg.Delivery = deliveryDelivered
+		msg.DeliveryError = ""
+		clearUndeliverable(ticket)
+	case isClientUnreachable(err):
+		msg.Delivery = deliveryBlocked
+		msg.DeliveryError = err.Error()
+	default:
+		msg.Delivery = deliveryFailed
+		msg.DeliveryError = err.Error()
+	}
+}
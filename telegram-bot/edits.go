@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MessageRevision — прежняя версия отредактированного сообщения тикета
+type MessageRevision struct {
+	Text string    `json:"text"`
+	Time time.Time `json:"time"` // когда текст был заменен новой версией
+}
+
+const recallCommand = "/recall"
+
+// editedMark — отметка об изменении сообщения
+func editedMark(msg Message) string {
+	if msg.EditedAt.IsZero() {
+		return ""
+	}
+	return "\n✏️ изменено " + msg.EditedAt.Format("02.01 15:04")
+}
+
+// handleEditedMessage обновляет сообщение тикета, если пользователь отредактировал его в Telegram,
+// и правит копии, разосланные ботом в другие чаты
+func handleEditedMessage(bot *tgbotapi.BotAPI, edited *tgbotapi.Message) {
+	ref, ok := telegramMessageIndex[MessageRef{ChatID: edited.Chat.ID, MessageID: edited.MessageID}]
+	if !ok || ref.MessageID == 0 {
+		return
+	}
+	ticket, exists := tickets[ref.TicketID]
+	if !exists {
+		return
+	}
+	msg := findTicketMessage(ticket, ref.MessageID)
+	// Правки принимаем только для исходного сообщения, а не для копий бота
+	if msg == nil || msg.TgMessageID != edited.MessageID || !msg.RecalledAt.IsZero() {
+		return
+	}
+
+	text := messageContent(edited)
+	if text == msg.Text {
+		return
+	}
+	now := time.Now()
+	msg.Revisions = append(msg.Revisions, MessageRevision{Text: msg.Text, Time: now})
+	msg.Text = text
+	msg.EditedAt = now
+	saveTickets()
+	log.Printf("Сообщение #%d тикета #%d отредактировано (версия %d)", msg.ID, ticket.ID, len(msg.Revisions)+1)
+
+	syncMessageMirrors(bot, ticket, msg)
+}
+
+// mirrorTextFor — текст копии сообщения тикета в указанном чате (в том же виде, в каком она была отправлена)
+func mirrorTextFor(ticket *Ticket, msg *Message, chatID int64) string {
+	switch {
+	case msg.IsFromManager && chatID == ticket.UserID:
+		return fmt.Sprintf("💬 Ответ от менеджера:\n\n%s%s", msg.Text, editedMark(*msg))
+	case msg.IsFromManager:
+		return fmt.Sprintf("👨‍💼 %s ответил клиенту:\n\n%s", managerDisplayName(msg.SenderID), messageDisplayText(*msg))
+	case isSupportGroupChat(chatID):
+		// Точная копия сообщения клиента в теме тикета
+		return msg.Text + editedMark(*msg)
+	default:
+		return fmt.Sprintf("💬 Новое сообщение от клиента (тикет #%d):\n\n%s", ticket.ID, messageDisplayText(*msg))
+	}
+}
+
+// syncMessageMirrors правит текстовые копии сообщения. Копии вложений подписаны меткой бота,
+// их подпись меняется у точных копий сообщений клиента в теме тикета и у ответа менеджера
+// клиенту, если ответ был без текста и подпись вложения — единственное место, где клиент видит текст.
+func syncMessageMirrors(bot *tgbotapi.BotAPI, ticket *Ticket, msg *Message) {
+	var mediaRefs []MessageRef
+	clientTextSynced := false
+	for _, ref := range msg.Mirrors {
+		text := mirrorTextFor(ticket, msg, ref.ChatID)
+		_, err := bot.Request(tgbotapi.NewEditMessageText(ref.ChatID, ref.MessageID, text))
+		if err == nil {
+			clientTextSynced = clientTextSynced || ref.ChatID == ticket.UserID
+			continue
+		}
+		if ref.ChatID == ticket.UserID && isClientUnreachable(err) {
+			markTicketUndeliverable(bot, ticket, err, 0)
+			continue
+		}
+		if len(msg.Attachments) > 0 {
+			mediaRefs = append(mediaRefs, ref)
+		}
+	}
+
+	clientCaptioned := false
+	for _, ref := range mediaRefs {
+		switch {
+		case !msg.IsFromManager && isSupportGroupChat(ref.ChatID):
+		case msg.IsFromManager && ref.ChatID == ticket.UserID && !clientTextSynced && !clientCaptioned:
+			// Текст ставим в подпись только первого вложения, остальные остаются с меткой бота
+			clientCaptioned = true
+		default:
+			continue
+		}
+		text := mirrorTextFor(ticket, msg, ref.ChatID)
+		if _, err := bot.Request(tgbotapi.NewEditMessageCaption(ref.ChatID, ref.MessageID, text)); err != nil {
+			log.Printf("Не удалось обновить копию сообщения #%d тикета #%d: %v", msg.ID, ticket.ID, err)
+			if ref.ChatID == ticket.UserID && isClientUnreachable(err) {
+				markTicketUndeliverable(bot, ticket, err, 0)
+			}
+		}
+	}
+}
+
+// recallButton — кнопка отзыва ответа менеджера
+func recallButton(ticketID, messageID int) tgbotapi.InlineKeyboardButton {
+	return tgbotapi.NewInlineKeyboardButtonData("↩️ Отозвать", fmt.Sprintf("recall_%d_%d", ticketID, messageID))
+}
+
+// recallManagerMessage удаляет ответ менеджера у клиента и помечает его в тикете как отозванный.
+// Возвращает текст результата для менеджера.
+func recallManagerMessage(bot *tgbotapi.BotAPI, ticket *Ticket, messageID int, actorID int64) string {
+	msg := findTicketMessage(ticket, messageID)
+	switch {
+	case msg == nil:
+		return "❌ Сообщение не найдено"
+	case !msg.IsFromManager:
+		return "❌ Отозвать можно только ответ менеджера"
+	case msg.SenderID != actorID:
+		return "❌ Отозвать можно только свой ответ"
+	case !msg.RecalledAt.IsZero():
+		return fmt.Sprintf("ℹ️ Сообщение #%d уже отозвано", messageID)
+	}
+
+	// Сначала удаляем копии у клиента: если Telegram не позволяет (старше 48 часов), ответ не отзываем
+	for _, ref := range msg.Mirrors {
+		if ref.ChatID != ticket.UserID {
+			continue
+		}
+		if _, err := bot.Request(tgbotapi.NewDeleteMessage(ref.ChatID, ref.MessageID)); err != nil {
+			log.Printf("Не удалось удалить сообщение #%d тикета #%d у клиента: %v", messageID, ticket.ID, err)
+			if isClientUnreachable(err) {
+				markTicketUndeliverable(bot, ticket, err, actorID)
+				return "❌ Не удалось удалить сообщение: клиент заблокировал бота или удалил аккаунт"
+			}
+			return "❌ Не удалось удалить сообщение у клиента: Telegram позволяет удалять сообщения бота только в течение 48 часов"
+		}
+	}
+	for _, ref := range msg.Mirrors {
+		if ref.ChatID != ticket.UserID {
+			bot.Request(tgbotapi.NewEditMessageText(ref.ChatID, ref.MessageID, "🗑 Ответ отозван:\n\n"+messageDisplayText(*msg)))
+		}
+	}
+
+	msg.RecalledAt = time.Now()
+	logTicketEvent(ticket, "recalled", actorID, fmt.Sprintf("%s отозвал ответ #%d", managerDisplayName(actorID), messageID))
+	saveTickets()
+	log.Printf("Менеджер %d отозвал сообщение #%d в тикете #%d", actorID, messageID, ticket.ID)
+	return fmt.Sprintf("🗑 Ответ #%d в тикете #%d отозван — у клиента он удален", messageID, ticket.ID)
+}
+
+// handleRecallCallback обрабатывает кнопку recall_<ID тикета>_<ID сообщения>
+func handleRecallCallback(bot *tgbotapi.BotAPI, chatID int64, actorID int64, data string) {
+	parts := strings.Split(strings.TrimPrefix(data, "recall_"), "_")
+	if len(parts) != 2 {
+		return
+	}
+	ticketID, err1 := strconv.Atoi(parts[0])
+	messageID, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	ticket, exists := tickets[ticketID]
+	if !exists || findTicketMessage(ticket, messageID) == nil {
+		// Кнопка могла остаться от тикета, сообщения которого перенесены при объединении или разделении
+		if moved, movedID := findMovedMessage(ticketID, messageID); moved != nil {
+			ticket, messageID, exists = moved, movedID, true
+		}
+	}
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, recallManagerMessage(bot, ticket, messageID, actorID)))
+}
+
+// isRecallCommand проверяет, является ли сообщение командой /recall
+func isRecallCommand(text string) bool {
+	fields := strings.Fields(text)
+	return len(fields) > 0 && strings.EqualFold(strings.SplitN(fields[0], "@", 2)[0], recallCommand)
+}
+
+// handleRecallCommand отзывает ответ менеджера командой /recall в reply на этот ответ или его копию.
+// topicTicket — тикет темы группы поддержки, в которой написана команда (nil в личном чате):
+// туда пишется результат.
+func handleRecallCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message, topicTicket *Ticket) bool {
+	if !isRecallCommand(message.Text) {
+		return false
+	}
+	reply := func(text string) {
+		if topicTicket != nil {
+			sendTopicMessage(bot, topicTicket, text)
+		} else {
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, text))
+		}
+	}
+
+	target, ok := lookupReplyTarget(message)
+	if !ok || target.MessageID == 0 {
+		reply("↩️ Использование: ответьте (reply) командой /recall на свой ответ клиенту")
+		return true
+	}
+	ticket, exists := tickets[target.TicketID]
+	if !exists {
+		reply("❌ Тикет не найден")
+		return true
+	}
+	reply(recallManagerMessage(bot, ticket, target.MessageID, message.From.ID))
+	return true
+}
@@ -92,7 +92,7 @@ func exportAllTicketsExcel() (*bytes.Buffer, error) {
 	f := excelize.NewFile()
 	sheet := f.GetSheetName(0)
 
-	headers := []string{"TicketID", "Status", "UserID", "Username", "FirstName", "LastName", "Height", "Chest", "Oversize", "Recommended", "Question", "CreatedAt", "LastMessage"}
+	headers := []string{"TicketID", "Status", "UserID", "Username", "FirstName", "LastName", "Height", "Chest", "Oversize", "Recommended", "Question", "CreatedAt", "LastMessage", "Product", "Measurements", "Category", "Priority", "Tags", "SLARemind", "SLAEscalate", "ClosedAt", "CloseReason", "Rating", "RatingComment", "RatedManager"}
 	for i, h := range headers {
 		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
 		f.SetCellValue(sheet, cell, h)
@@ -121,6 +121,32 @@ func exportAllTicketsExcel() (*bytes.Buffer, error) {
 		f.SetCellValue(sheet, fmt.Sprintf("K%d", rowIdx), t.Question)
 		f.SetCellValue(sheet, fmt.Sprintf("L%d", rowIdx), t.CreatedAt.Format("2006-01-02 15:04:05"))
 		f.SetCellValue(sheet, fmt.Sprintf("M%d", rowIdx), t.LastMessage.Format("2006-01-02 15:04:05"))
+		f.SetCellValue(sheet, fmt.Sprintf("N%d", rowIdx), t.Product)
+		f.SetCellValue(sheet, fmt.Sprintf("O%d", rowIdx), formatMeasurements(t.Measurements))
+		f.SetCellValue(sheet, fmt.Sprintf("P%d", rowIdx), t.Category)
+		f.SetCellValue(sheet, fmt.Sprintf("Q%d", rowIdx), ticketPriority(t))
+		f.SetCellValue(sheet, fmt.Sprintf("R%d", rowIdx), strings.Join(t.Tags, ", "))
+		remind, escalate := 0, 0
+		for _, b := range t.SLABreaches {
+			if b.Kind == "escalate" {
+				escalate++
+			} else {
+				remind++
+			}
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("S%d", rowIdx), remind)
+		f.SetCellValue(sheet, fmt.Sprintf("T%d", rowIdx), escalate)
+		if !t.ClosedAt.IsZero() {
+			f.SetCellValue(sheet, fmt.Sprintf("U%d", rowIdx), t.ClosedAt.Format("2006-01-02 15:04:05"))
+		}
+		f.SetCellValue(sheet, fmt.Sprintf("V%d", rowIdx), t.CloseReason)
+		if t.Rating != nil {
+			f.SetCellValue(sheet, fmt.Sprintf("W%d", rowIdx), t.Rating.Score)
+			f.SetCellValue(sheet, fmt.Sprintf("X%d", rowIdx), t.Rating.Comment)
+			if t.Rating.ManagerID != 0 {
+				f.SetCellValue(sheet, fmt.Sprintf("Y%d", rowIdx), managerDisplayName(t.Rating.ManagerID))
+			}
+		}
 	}
 
 	// Настроим ширины и шапку
@@ -131,12 +157,20 @@ func exportAllTicketsExcel() (*bytes.Buffer, error) {
 	_ = f.SetColWidth(sheet, "G", "H", 10)
 	_ = f.SetColWidth(sheet, "I", "K", 18)
 	_ = f.SetColWidth(sheet, "L", "M", 20)
+	_ = f.SetColWidth(sheet, "N", "O", 30)
+	_ = f.SetColWidth(sheet, "P", "Q", 12)
+	_ = f.SetColWidth(sheet, "R", "R", 30)
+	_ = f.SetColWidth(sheet, "S", "T", 12)
+	_ = f.SetColWidth(sheet, "U", "U", 20)
+	_ = f.SetColWidth(sheet, "V", "W", 12)
+	_ = f.SetColWidth(sheet, "X", "X", 40)
+	_ = f.SetColWidth(sheet, "Y", "Y", 20)
 	_ = f.SetPanes(sheet, &excelize.Panes{Freeze: true, Split: true, XSplit: 0, YSplit: 1})
 
 	// Лист сообщений по всем тикетам
 	msgSheet := "Messages"
 	f.NewSheet(msgSheet)
-	msgHeaders := []string{"TicketID", "#", "SenderID", "FromManager", "Time", "Text"}
+	msgHeaders := []string{"TicketID", "#", "SenderID", "FromManager", "Time", "Text", "Attachments", "EditedAt", "Recalled", "Delivery"}
 	for i, h := range msgHeaders {
 		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
 		f.SetCellValue(msgSheet, cell, h)
@@ -156,19 +190,54 @@ func exportAllTicketsExcel() (*bytes.Buffer, error) {
 			f.SetCellValue(msgSheet, fmt.Sprintf("D%d", r), m.IsFromManager)
 			f.SetCellValue(msgSheet, fmt.Sprintf("E%d", r), m.Time.Format("2006-01-02 15:04:05"))
 			f.SetCellValue(msgSheet, fmt.Sprintf("F%d", r), strings.ReplaceAll(m.Text, "\n", " "))
+			f.SetCellValue(msgSheet, fmt.Sprintf("G%d", r), attachmentExportText(m.Attachments))
+			if !m.EditedAt.IsZero() {
+				f.SetCellValue(msgSheet, fmt.Sprintf("H%d", r), m.EditedAt.Format("2006-01-02 15:04:05"))
+			}
+			f.SetCellValue(msgSheet, fmt.Sprintf("I%d", r), !m.RecalledAt.IsZero())
+			f.SetCellValue(msgSheet, fmt.Sprintf("J%d", r), m.Delivery)
 			r++
 		}
 	}
 	_ = f.SetColWidth(msgSheet, "A", "E", 14)
 	_ = f.SetColWidth(msgSheet, "F", "F", 80)
+	_ = f.SetColWidth(msgSheet, "G", "G", 40)
+	_ = f.SetColWidth(msgSheet, "H", "J", 20)
 	_ = f.SetPanes(msgSheet, &excelize.Panes{Freeze: true, Split: true, XSplit: 0, YSplit: 1})
 
+	// Лист оценок клиентов по менеджерам
+	ratingSheet := "Ratings"
+	f.NewSheet(ratingSheet)
+	ratingHeaders := []string{"ManagerID", "Manager", "Ratings", "Average", "WithComment", "1", "2", "3", "4", "5"}
+	for i, h := range ratingHeaders {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(ratingSheet, cell, h)
+	}
+	managerRatings, _ := collectRatings()
+	for i, m := range managerRatings {
+		row := i + 2
+		f.SetCellValue(ratingSheet, fmt.Sprintf("A%d", row), m.ManagerID)
+		if m.ManagerID != 0 {
+			f.SetCellValue(ratingSheet, fmt.Sprintf("B%d", row), managerDisplayName(m.ManagerID))
+		}
+		f.SetCellValue(ratingSheet, fmt.Sprintf("C%d", row), m.Count)
+		f.SetCellValue(ratingSheet, fmt.Sprintf("D%d", row), fmt.Sprintf("%.2f", m.Average()))
+		f.SetCellValue(ratingSheet, fmt.Sprintf("E%d", row), m.Comments)
+		for score := 1; score <= maxRatingScore; score++ {
+			cell, _ := excelize.CoordinatesToCellName(5+score, row)
+			f.SetCellValue(ratingSheet, cell, m.Scores[score])
+		}
+	}
+	_ = f.SetColWidth(ratingSheet, "A", "A", 14)
+	_ = f.SetColWidth(ratingSheet, "B", "B", 24)
+	_ = f.SetColWidth(ratingSheet, "C", "E", 12)
+
 	// Стили: перенос текста для колонки F (Text) и жирная шапка
 	wrapStyle, _ := f.NewStyle(&excelize.Style{Alignment: &excelize.Alignment{WrapText: true, Vertical: "top"}})
 	headerStyle, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
 	// применяем к шапкам обоих листов
-	_ = f.SetCellStyle(sheet, "A1", "M1", headerStyle)
-	_ = f.SetCellStyle(msgSheet, "A1", "F1", headerStyle)
+	_ = f.SetCellStyle(sheet, "A1", "Y1", headerStyle)
+	_ = f.SetCellStyle(msgSheet, "A1", "J1", headerStyle)
 	// применяем перенос для всех ячеек текста F2:F{r-1}
 	if r > 2 {
 		_ = f.SetCellStyle(msgSheet, "F2", fmt.Sprintf("F%d", r-1), wrapStyle)
@@ -210,6 +279,19 @@ func exportSingleTicketExcel(ticketID int) (*bytes.Buffer, error) {
 		{"Question", t.Question},
 		{"CreatedAt", t.CreatedAt.Format("2006-01-02 15:04:05")},
 		{"LastMessage", t.LastMessage.Format("2006-01-02 15:04:05")},
+		{"Product", t.Product},
+		{"Measurements", formatMeasurements(t.Measurements)},
+	}
+	if t.Rating != nil {
+		rated := ""
+		if t.Rating.ManagerID != 0 {
+			rated = managerDisplayName(t.Rating.ManagerID)
+		}
+		rows = append(rows,
+			[]any{"Rating", t.Rating.Score},
+			[]any{"RatingComment", t.Rating.Comment},
+			[]any{"RatedManager", rated},
+		)
 	}
 	for i, row := range rows {
 		f.SetCellValue(mainSheet, fmt.Sprintf("A%d", i+1), row[0])
@@ -219,7 +301,7 @@ func exportSingleTicketExcel(ticketID int) (*bytes.Buffer, error) {
 	// Лист сообщений
 	messagesSheet := "Messages"
 	f.NewSheet(messagesSheet)
-	msgHeaders := []string{"#", "SenderID", "FromManager", "Time", "Text"}
+	msgHeaders := []string{"#", "SenderID", "FromManager", "Time", "Text", "Attachments", "EditedAt", "Recalled", "Delivery"}
 	for i, h := range msgHeaders {
 		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
 		f.SetCellValue(messagesSheet, cell, h)
@@ -231,6 +313,48 @@ func exportSingleTicketExcel(ticketID int) (*bytes.Buffer, error) {
 		f.SetCellValue(messagesSheet, fmt.Sprintf("C%d", rowIdx), m.IsFromManager)
 		f.SetCellValue(messagesSheet, fmt.Sprintf("D%d", rowIdx), m.Time.Format("2006-01-02 15:04:05"))
 		f.SetCellValue(messagesSheet, fmt.Sprintf("E%d", rowIdx), strings.ReplaceAll(m.Text, "\n", " "))
+		f.SetCellValue(messagesSheet, fmt.Sprintf("F%d", rowIdx), attachmentExportText(m.Attachments))
+		if !m.EditedAt.IsZero() {
+			f.SetCellValue(messagesSheet, fmt.Sprintf("G%d", rowIdx), m.EditedAt.Format("2006-01-02 15:04:05"))
+		}
+		f.SetCellValue(messagesSheet, fmt.Sprintf("H%d", rowIdx), !m.RecalledAt.IsZero())
+		f.SetCellValue(messagesSheet, fmt.Sprintf("I%d", rowIdx), m.Delivery)
+	}
+
+	// Лист прежних версий отредактированных сообщений
+	revisionsSheet := "Revisions"
+	f.NewSheet(revisionsSheet)
+	revisionHeaders := []string{"MessageID", "Version", "ReplacedAt", "Text"}
+	for i, h := range revisionHeaders {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(revisionsSheet, cell, h)
+	}
+	revRow := 2
+	for _, m := range t.Messages {
+		for v, rev := range m.Revisions {
+			f.SetCellValue(revisionsSheet, fmt.Sprintf("A%d", revRow), m.ID)
+			f.SetCellValue(revisionsSheet, fmt.Sprintf("B%d", revRow), v+1)
+			f.SetCellValue(revisionsSheet, fmt.Sprintf("C%d", revRow), rev.Time.Format("2006-01-02 15:04:05"))
+			f.SetCellValue(revisionsSheet, fmt.Sprintf("D%d", revRow), strings.ReplaceAll(rev.Text, "\n", " "))
+			revRow++
+		}
+	}
+
+	// Лист внутренних заметок менеджеров
+	notesSheet := "Notes"
+	f.NewSheet(notesSheet)
+	noteHeaders := []string{"#", "AuthorID", "Author", "Time", "Text"}
+	for i, h := range noteHeaders {
+		cell, _ := excelize.CoordinatesToCellName(i+1, 1)
+		f.SetCellValue(notesSheet, cell, h)
+	}
+	for idx, n := range t.Notes {
+		rowIdx := idx + 2
+		f.SetCellValue(notesSheet, fmt.Sprintf("A%d", rowIdx), n.ID)
+		f.SetCellValue(notesSheet, fmt.Sprintf("B%d", rowIdx), n.AuthorID)
+		f.SetCellValue(notesSheet, fmt.Sprintf("C%d", rowIdx), managerDisplayName(n.AuthorID))
+		f.SetCellValue(notesSheet, fmt.Sprintf("D%d", rowIdx), n.Time.Format("2006-01-02 15:04:05"))
+		f.SetCellValue(notesSheet, fmt.Sprintf("E%d", rowIdx), strings.ReplaceAll(n.Text, "\n", " "))
 	}
 
 	buf, err := f.WriteToBuffer()
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// defaultGarmentType — тип изделия для товаров без явно указанного типа
+const defaultGarmentType = "tshirt"
+
+// garmentType описывает анкету подбора размера для типа изделия
+type garmentType struct {
+	Name         string
+	Measurements []string // ключи measurementSteps в порядке вопросов
+	Oversize     bool     // задавать ли вопрос об оверсайзе
+	Recommend    func(values map[string]int, oversize bool) sizeRecommendation
+}
+
+var garmentTypes = map[string]*garmentType{
+	"tshirt": {
+		Name:         "Футболка",
+		Measurements: []string{"height", "chest"},
+		Oversize:     true,
+		Recommend:    recommendTopSize,
+	},
+	"hoodie": {
+		Name:         "Худи",
+		Measurements: []string{"height", "chest"},
+		Oversize:     true,
+		Recommend:    recommendTopSize,
+	},
+	"trousers": {
+		Name:         "Брюки",
+		Measurements: []string{"height", "waist", "inseam"},
+		Recommend:    recommendTrousersSize,
+	},
+	"cap": {
+		Name:         "Кепка",
+		Measurements: []string{"head"},
+		Recommend:    recommendCapSize,
+	},
+}
+
+// measurementSteps — все известные замеры: вопрос, диапазон допустимых значений и быстрые варианты
+var measurementSteps = map[string]measurementStep{
+	"height": {
+		Key:        "height",
+		Icon:       "📏",
+		Name:       "Рост",
+		Prompt:     "Ваш рост? (в см)",
+		Retry:      "Пожалуйста, введите рост в сантиметрах (например: 175)",
+		OutOfRange: "Рост должен быть от 100 до 250 см. Попробуйте еще раз:",
+		Min:        100,
+		Max:        250,
+		QuickPicks: []quickPick{{"до 158", 152}, {"158-175", 167}, {"176-188", 182}, {"от 189", 192}},
+		Guide: "Встаньте без обуви спиной к стене, пятки вместе, взгляд прямо. " +
+			"Положите на макушку книгу горизонтально и отметьте на стене нижний край. " +
+			"Измерьте расстояние от пола до отметки.",
+	},
+	"chest": {
+		Key:        "chest",
+		Icon:       "📐",
+		Name:       "Обхват груди",
+		Prompt:     "Обхват груди? (в см)",
+		Retry:      "Пожалуйста, введите обхват груди в сантиметрах (например: 90)",
+		OutOfRange: "Обхват груди должен быть от 70 до 130 см. Попробуйте еще раз:",
+		Min:        70,
+		Max:        130,
+		QuickPicks: []quickPick{{"82-89", 86}, {"90-97", 94}, {"98-105", 102}, {"106-113", 110}, {"114-121", 118}},
+		Guide: "Измеряйте в белье или тонкой футболке. " +
+			"Сантиметровая лента проходит горизонтально по самым выступающим точкам груди, под мышками и по лопаткам. " +
+			"Лента прилегает плотно, но не сдавливает; дышите спокойно.",
+		FromItem: &itemMeasure{
+			Prompt:  "Положите на ровную поверхность футболку, которая хорошо на вас сидит, и измерьте ширину от подмышки до подмышки (в см):",
+			Retry:   "Пожалуйста, введите ширину футболки в сантиметрах, от 35 до 75 (например: 52)",
+			Min:     35,
+			Max:     75,
+			Convert: func(width int) int { return width*2 - 8 }, // минус свободное облегание
+		},
+	},
+	"waist": {
+		Key:        "waist",
+		Icon:       "📐",
+		Name:       "Обхват талии",
+		Prompt:     "Обхват талии? (в см)",
+		Retry:      "Пожалуйста, введите обхват талии в сантиметрах (например: 80)",
+		OutOfRange: "Обхват талии должен быть от 50 до 130 см. Попробуйте еще раз:",
+		Min:        50,
+		Max:        130,
+		QuickPicks: []quickPick{{"66-73", 70}, {"74-81", 78}, {"82-89", 86}, {"90-97", 94}, {"98-105", 102}},
+		Guide:      "Лента проходит по самой узкой части талии, обычно на 2–3 см выше пупка. Не втягивайте живот.",
+		FromItem: &itemMeasure{
+			Prompt:  "Застегните брюки, которые хорошо на вас сидят, положите их ровно и измерьте ширину пояса (в см):",
+			Retry:   "Пожалуйста, введите ширину пояса в сантиметрах, от 25 до 65 (например: 40)",
+			Min:     25,
+			Max:     65,
+			Convert: func(width int) int { return width * 2 },
+		},
+	},
+	"inseam": {
+		Key:        "inseam",
+		Icon:       "📏",
+		Name:       "Длина по внутреннему шву",
+		Prompt:     "Длина ноги по внутреннему шву? (в см)",
+		Retry:      "Пожалуйста, введите длину по внутреннему шву в сантиметрах (например: 80)",
+		OutOfRange: "Длина по внутреннему шву должна быть от 60 до 100 см. Попробуйте еще раз:",
+		Min:        60,
+		Max:        100,
+		QuickPicks: []quickPick{{"до 76", 74}, {"76-80", 78}, {"81-85", 83}, {"от 86", 88}},
+		Guide:      "Встаньте прямо, ноги на ширине плеч. Измерьте расстояние от паха до пола по внутренней стороне ноги.",
+		FromItem: &itemMeasure{
+			Prompt:  "Измерьте брюки, которые хорошо на вас сидят: от шагового шва до низа штанины (в см):",
+			Retry:   "Пожалуйста, введите длину в сантиметрах, от 60 до 100 (например: 80)",
+			Min:     60,
+			Max:     100,
+			Convert: func(length int) int { return length },
+		},
+	},
+	"head": {
+		Key:        "head",
+		Icon:       "🧢",
+		Name:       "Обхват головы",
+		Prompt:     "Обхват головы? (в см)",
+		Retry:      "Пожалуйста, введите обхват головы в сантиметрах (например: 57)",
+		OutOfRange: "Обхват головы должен быть от 48 до 66 см. Попробуйте еще раз:",
+		Min:        48,
+		Max:        66,
+		QuickPicks: []quickPick{{"52-54", 53}, {"55-57", 56}, {"58-60", 59}, {"61-63", 62}},
+		Guide:      "Лента проходит горизонтально по лбу на 1–2 см выше бровей, над ушами и по самой выступающей точке затылка.",
+		FromItem: &itemMeasure{
+			Prompt:  "Измерьте окружность по внутреннему ободку кепки, которая хорошо на вас сидит (в см):",
+			Retry:   "Пожалуйста, введите окружность в сантиметрах, от 48 до 66 (например: 57)",
+			Min:     48,
+			Max:     66,
+			Convert: func(circumference int) int { return circumference },
+		},
+	},
+}
+
+// sizeTableRow — строка размерной таблицы по одному замеру
+type sizeTableRow struct {
+	Min  int
+	Max  int
+	Mark string
+	RU   string
+}
+
+// topSizeTable — универсальная таблица для футболок и худи (по обхвату груди)
+var topSizeTable = []sizeTableRow{
+	{82, 89, "XS-S", "42-44"},
+	{90, 97, "M-L", "46-48"},
+	{98, 105, "XL-2XL", "50-52"},
+	{106, 113, "3XL-4XL", "54-56"},
+	{114, 121, "5XL-6XL", "58-60"},
+}
+
+// trousersSizeTable — таблица для брюк (по обхвату талии)
+var trousersSizeTable = []sizeTableRow{
+	{66, 73, "XS-S", "42-44"},
+	{74, 81, "M-L", "46-48"},
+	{82, 89, "XL-2XL", "50-52"},
+	{90, 97, "3XL-4XL", "54-56"},
+	{98, 105, "5XL-6XL", "58-60"},
+}
+
+// capSizeTable — таблица для кепок (по обхвату головы)
+var capSizeTable = []sizeTableRow{
+	{52, 54, "S", "53"},
+	{55, 57, "M", "56"},
+	{58, 60, "L", "59"},
+	{61, 63, "XL", "62"},
+}
+
+// lookupSizeTable подбирает строку таблицы по замеру и оценивает точность подбора
+func lookupSizeTable(table []sizeTableRow, value int, label string, oversize bool) sizeRecommendation {
+	confidence := sizeConfidenceHigh
+	reason := ""
+	idx := -1
+	for i, r := range table {
+		if value >= r.Min && value <= r.Max {
+			idx = i
+			if i > 0 && value-r.Min < sizeBorderlineMargin {
+				confidence = sizeConfidenceMedium
+				reason = fmt.Sprintf("%s %d см — на границе размеров %s и %s", label, value, table[i-1].Mark, r.Mark)
+			} else if i < len(table)-1 && r.Max-value < sizeBorderlineMargin {
+				confidence = sizeConfidenceMedium
+				reason = fmt.Sprintf("%s %d см — на границе размеров %s и %s", label, value, r.Mark, table[i+1].Mark)
+			}
+			break
+		}
+	}
+	if idx == -1 {
+		if value < table[0].Min {
+			idx = 0
+		} else {
+			idx = len(table) - 1
+		}
+		confidence = sizeConfidenceLow
+		reason = fmt.Sprintf("%s %d см вне размерной таблицы (%d–%d см)", label, value, table[0].Min, table[len(table)-1].Max)
+	}
+	if oversize {
+		if idx < len(table)-1 {
+			idx++
+		} else if confidence == sizeConfidenceHigh {
+			confidence = sizeConfidenceLow
+			reason = "Оверсайз для максимального размера таблицы недоступен"
+		}
+	}
+	return sizeRecommendation{
+		Mark:       table[idx].Mark,
+		RU:         table[idx].RU,
+		Confidence: confidence,
+		Reason:     reason,
+	}
+}
+
+func recommendTopSize(values map[string]int, oversize bool) sizeRecommendation {
+	return recommendSize(values["chest"], oversize)
+}
+
+func recommendTrousersSize(values map[string]int, _ bool) sizeRecommendation {
+	rec := lookupSizeTable(trousersSizeTable, values["waist"], "Обхват талии", false)
+	if inseam := values["inseam"]; inseam > 0 && (inseam < 72 || inseam > 86) && rec.Confidence == sizeConfidenceHigh {
+		rec.Confidence = sizeConfidenceMedium
+		rec.Reason = fmt.Sprintf("Длина по внутреннему шву %d см — брюки могут оказаться короче или длиннее", inseam)
+	}
+	return rec
+}
+
+func recommendCapSize(values map[string]int, _ bool) sizeRecommendation {
+	return lookupSizeTable(capSizeTable, values["head"], "Обхват головы", false)
+}
+
+// productGarment возвращает анкету для товара (по умолчанию — футболка)
+func productGarment(idx int) *garmentType {
+	if idx >= 0 && idx < len(products) {
+		if g, ok := garmentTypes[products[idx].Type]; ok {
+			return g
+		}
+	}
+	return garmentTypes[defaultGarmentType]
+}
+
+// formatMeasurements форматирует замеры в строку вида "Обхват талии: 80 см, ..."
+func formatMeasurements(values map[string]int) string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		name := k
+		if step, ok := measurementSteps[k]; ok {
+			name = step.Name
+		}
+		parts = append(parts, fmt.Sprintf("%s: %d см", name, values[k]))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -14,12 +15,17 @@ import (
 )
 
 type UserState struct {
-	Step            int
-	SelectedTee     string
-	Height          int
-	ChestSize       int
-	Oversize        bool
-	RecommendedSize string
+	Step             int
+	MeasureIdx       int  // индекс замера на шаге stepMeasure
+	Editing          bool // true если клиент правит ответ из сводки
+	ItemMode         bool // true если клиент вводит замер по своей вещи
+	SelectedTee      string
+	Height           int
+	ChestSize        int
+	Oversize         bool
+	OversizeAnswered bool
+	Measurements     map[string]int // замеры кроме роста и обхвата груди (талия, голова и т.д.)
+	RecommendedSize  string
 }
 
 type Product struct {
@@ -27,17 +33,24 @@ type Product struct {
 	Sizes    []string
 	Link     string
 	ImageURL string
+	Type     string // ключ garmentTypes: "tshirt", "hoodie", "trousers", "cap"
+	Oversize bool   // доступна ли оверсайз-посадка
 }
 
+// stateMu защищает глобальное состояние бота (тикеты, состояния пользователей),
+// которое меняется и из цикла обновлений, и из фоновых задач. Фоновые задачи не держат его
+// во время запросов к Telegram (см. deliverOutbox).
+var stateMu sync.Mutex
+
 var userStates = make(map[int64]*UserState)
 var questionStates = make(map[int64]bool)    // true если пользователь в режиме вопроса менеджеру
 var messageModeStates = make(map[int64]bool) // true если пользователь в режиме написания сообщения в тикет
 var nameCollectState = make(map[int64]bool)  // true если ожидаем имя клиента для контакта с менеджером
 
 var products = []Product{
-	{"Футболка Крылатые Фразы белая", []string{"S", "M", "L", "XL", "XXL"}, "https://osteomerch.com/katalog/item/colorful-jumper-with-horizontal-stripes/", "./katalog/Крылатые Фразы/1.jpg"},
-	{"Футболка Black to Black черная", []string{"S", "M", "L", "XL", "XXL"}, "https://osteomerch.com/katalog/item/black-suede-pleated-skirt/", "./katalog/Black to Black/1.jpg"},
-	{"Футболка Black to Black 2 черная", []string{"S", "M", "L", "XL", "XXL"}, "https://osteomerch.com/katalog/item/black-wide-suede-pants-with-white-stripes/", "./katalog/Black to Black 2/1.jpg"},
+	{"Футболка Крылатые Фразы белая", []string{"S", "M", "L", "XL", "XXL"}, "https://osteomerch.com/katalog/item/colorful-jumper-with-horizontal-stripes/", "./katalog/Крылатые Фразы/1.jpg", "tshirt", true},
+	{"Футболка Black to Black черная", []string{"S", "M", "L", "XL", "XXL"}, "https://osteomerch.com/katalog/item/black-suede-pleated-skirt/", "./katalog/Black to Black/1.jpg", "tshirt", false},
+	{"Футболка Black to Black 2 черная", []string{"S", "M", "L", "XL", "XXL"}, "https://osteomerch.com/katalog/item/black-wide-suede-pants-with-white-stripes/", "./katalog/Black to Black 2/1.jpg", "tshirt", false},
 }
 
 func main() {
@@ -52,6 +65,9 @@ func main() {
 	// Инициализируем роли
 	initAdmins()
 	initManagers()
+	initSupportGroup()
+	loadTemplates()
+	loadClients()
 
 	bot, err := tgbotapi.NewBotAPI(os.Getenv("TELEGRAM_BOT_TOKEN"))
 	if err != nil {
@@ -67,6 +83,15 @@ func main() {
 	// Запускаем самопинг
 	startSelfPing()
 
+	// Запускаем проверку SLA по открытым тикетам
+	startSLAScheduler(bot)
+
+	// Запускаем автозакрытие неактивных тикетов
+	startAutoCloseScheduler(bot)
+
+	// Периодически сохраняем профили клиентов
+	startClientsSaver()
+
 	// Бесконечный цикл с восстановлением
 	for {
 		runBot(bot)
@@ -88,11 +113,21 @@ func runBot(bot *tgbotapi.BotAPI) {
 	updates := bot.GetUpdatesChan(u)
 
 	for update := range updates {
-		if update.Message != nil {
-			handleMessage(bot, update.Message)
-		} else if update.CallbackQuery != nil {
-			handleCallbackQuery(bot, update.CallbackQuery)
-		}
+		handleUpdate(bot, update)
+	}
+}
+
+// handleUpdate обрабатывает обновление под блокировкой общего состояния
+func handleUpdate(bot *tgbotapi.BotAPI, update tgbotapi.Update) {
+	stateMu.Lock()
+	defer stateMu.Unlock()
+
+	if update.Message != nil {
+		handleMessage(bot, update.Message)
+	} else if update.EditedMessage != nil {
+		handleEditedMessage(bot, update.EditedMessage)
+	} else if update.CallbackQuery != nil {
+		handleCallbackQuery(bot, update.CallbackQuery)
 	}
 }
 
@@ -171,6 +206,16 @@ func startSelfPing() {
 
 func handleMessage(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
+	rememberManagerName(message.From)
+	if message.Chat.IsPrivate() {
+		touchClient(message.From)
+	}
+
+	// Сообщения в группе поддержки — только ответы менеджеров в темах тикетов
+	if isSupportGroupChat(chatID) {
+		handleSupportGroupMessage(bot, message)
+		return
+	}
 
 	switch message.Text {
 	case "/start":
@@ -216,12 +261,14 @@ func handleMessage(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 				return
 			}
 
-			// Убедимся, что есть тикет
-			if _, exists := userTickets[chatID]; !exists {
+			// Убедимся, что есть открытый тикет по выбранной теме; иначе создаем новый
+			category := contactCategoryState[chatID]
+			if ticket, ok := selectedOpenTicket(chatID); !ok || (category != "" && ticket.Category != "" && ticket.Category != category) {
 				createTicketAndAskQuestion(bot, chatID, "Не определен")
 			}
 			if ticketID, ok := userTickets[chatID]; ok {
 				updateTicketUserInfo(ticketID, message.From.UserName, providedName, "")
+				applyContactCategory(chatID, ticketID)
 			}
 			delete(nameCollectState, chatID)
 			bot.Send(tgbotapi.NewMessage(chatID, "Спасибо! Теперь напишите ваш вопрос менеджеру."))
@@ -229,9 +276,13 @@ func handleMessage(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 			questionStates[chatID] = true
 			return
 		}
+		// Комментарий клиента к оценке закрытого тикета
+		if handleRatingComment(bot, message) {
+			return
+		}
 		// Обработка поиска тикетов для менеджеров
 		if isManagerUser(message.From) {
-			if handleTicketSearchInput(bot, message) || handleExportTicketIDInput(bot, message) {
+			if handleTicketSearchInput(bot, message) || handleExportTicketIDInput(bot, message) || handleTagInput(bot, message) || handleTemplateInput(bot, message) || handleTemplateDraftInput(bot, message) || handleNoteInput(bot, message) || handleTransferNoteInput(bot, message) || handleSplitInput(bot, message) || handleNoteCommand(bot, message) || handleRecallCommand(bot, message, nil) {
 				return
 			}
 		}
@@ -242,7 +293,7 @@ func handleMessage(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 			}
 		}
 		// Проверяем, является ли это ответом менеджера
-		if isManagerResponse(message) {
+		if isManagerResponse(message) && managerHandlesMessage(message) {
 			handleManagerResponse(bot, message)
 			return
 		}
@@ -296,6 +347,12 @@ func sendMainMenu(bot *tgbotapi.BotAPI, chatID int64) {
 			tgbotapi.NewInlineKeyboardButtonData("Вернуться в тикет", "back_to_ticket"),
 		))
 	}
+	// Список обращений, если тикетов несколько
+	if len(clientTicketList(chatID)) > 1 {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📂 Мои обращения", "client_tickets"),
+		))
+	}
 
 	// Всегда добавляем кнопку связи с менеджером
 	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
@@ -312,20 +369,35 @@ func handleTeeSelection(bot *tgbotapi.BotAPI, callback *tgbotapi.CallbackQuery)
 
 	state, exists := userStates[chatID]
 	if !exists {
-		state = &UserState{Step: 1}
+		state = &UserState{Step: stepProduct}
 		userStates[chatID] = state
 	}
 
-	state.Step = 2
+	previousSteps := surveyMeasurementsFor(state)
+	state.Step = stepProduct
 	state.SelectedTee = selectedTee
+	if _, ok := surveyProductIndex(state); ok {
+		recordProductPick(chatID, surveyProductName(state))
+	}
+	if !surveyAllowsOversize(state) {
+		state.Oversize = false
+		state.OversizeAnswered = false
+	}
+	// Если у нового товара другая анкета — продолжаем опрос с первого вопроса без ответа
+	if state.Editing && !sameMeasurementSteps(previousSteps, surveyMeasurementsFor(state)) {
+		state.Editing = false
+	}
 
-	msg := tgbotapi.NewMessage(chatID, "Ваш рост? (в см)")
-	bot.Send(msg)
+	advanceSurvey(bot, chatID, state)
 }
 
 func handleCallbackQuery(bot *tgbotapi.BotAPI, callback *tgbotapi.CallbackQuery) {
 	chatID := callback.Message.Chat.ID
 	log.Printf("Получен callback: %s для чата %d", callback.Data, chatID)
+	rememberManagerName(callback.From)
+	if callback.Message.Chat.IsPrivate() {
+		touchClient(callback.From)
+	}
 
 	switch callback.Data {
 	case "select":
@@ -333,6 +405,7 @@ func handleCallbackQuery(bot *tgbotapi.BotAPI, callback *tgbotapi.CallbackQuery)
 		startSurvey(bot, chatID)
 	case "browse":
 		log.Printf("Показ каталога для чата %d", chatID)
+		recordCatalogView(chatID)
 		showCatalog(bot, chatID)
 	case "back_to_menu":
 		// Переназначаем поведение для менеджеров: возвращаем в менеджерское меню
@@ -346,6 +419,11 @@ func handleCallbackQuery(bot *tgbotapi.BotAPI, callback *tgbotapi.CallbackQuery)
 		handleOversizeCallback(bot, chatID, true)
 	case "oversize_no":
 		handleOversizeCallback(bot, chatID, false)
+	case "size_all_saved":
+		showSavedAllProductsRecommendations(bot, chatID)
+	case "size_clarify":
+		log.Printf("Уточнение размера у менеджера для чата %d", chatID)
+		createSizeClarificationTicket(bot, chatID, callback.From)
 	case "manager_tickets":
 		handleManagerTicketsCallback(bot, chatID)
 	case "manager_open_tickets":
@@ -382,6 +460,19 @@ func handleCallbackQuery(bot *tgbotapi.BotAPI, callback *tgbotapi.CallbackQuery)
 			msg := tgbotapi.NewMessage(chatID, "Введите номер тикета для экспорта в Excel (или /cancel)")
 			bot.Send(msg)
 		}
+	case "manager_toggle_shift":
+		if isManagerUser(callback.From) {
+			setOnShift(chatID, !isOnShift(chatID))
+			sendManagerMenu(bot, chatID)
+		}
+	case "manager_templates":
+		if isManagerUser(callback.From) {
+			showTemplatesMenu(bot, chatID)
+		}
+	case "manager_session_exit":
+		if isManagerUser(callback.From) {
+			handleManagerSessionExit(bot, chatID)
+		}
 	case "back_to_manager_menu":
 		sendManagerMenu(bot, chatID)
 	case "start_survey":
@@ -434,6 +525,9 @@ func handleCallbackQuery(bot *tgbotapi.BotAPI, callback *tgbotapi.CallbackQuery)
 	case "ticket_write_message":
 		log.Printf("Режим написания сообщения для чата %d", chatID)
 		startClientMessageMode(bot, chatID)
+	case "client_tickets":
+		log.Printf("Список обращений для чата %d", chatID)
+		showClientTicketsList(bot, chatID)
 	case "create_new_ticket":
 		log.Printf("Создание нового тикета для чата %d", chatID)
 		createNewClientTicket(bot, chatID)
@@ -443,8 +537,42 @@ func handleCallbackQuery(bot *tgbotapi.BotAPI, callback *tgbotapi.CallbackQuery)
 		if strings.HasPrefix(callback.Data, "tee_") {
 			log.Printf("Обработка выбора товара для чата %d", chatID)
 			handleTeeSelection(bot, callback)
+		} else if strings.HasPrefix(callback.Data, "survey_") {
+			handleSurveyCallback(bot, chatID, callback.Data)
 		} else if strings.HasPrefix(callback.Data, "ticket_") {
-			handleTicketButtonCallback(bot, chatID, callback.Data)
+			// Кнопки управления тикетом — только для менеджеров ("ticket_write_message" клиента обрабатывается выше)
+			if isManagerUser(callback.From) {
+				handleTicketButtonCallback(bot, chatID, callback.Data)
+			}
+		} else if strings.HasPrefix(callback.Data, "contact_category_") {
+			log.Printf("Выбор темы обращения для чата %d: %s", chatID, callback.Data)
+			handleContactCategoryCallback(bot, chatID, callback.Data)
+		} else if strings.HasPrefix(callback.Data, "profile_") {
+			if isManagerUser(callback.From) {
+				handleClientProfileCallback(bot, chatID, callback.Data)
+			}
+		} else if strings.HasPrefix(callback.Data, "template_") {
+			if isManagerUser(callback.From) {
+				handleTemplateCallback(bot, chatID, callback.Data)
+			}
+		} else if strings.HasPrefix(callback.Data, "recall_") {
+			if isManagerUser(callback.From) {
+				handleRecallCallback(bot, chatID, callback.From.ID, callback.Data)
+			}
+		} else if strings.HasPrefix(callback.Data, "rate_") {
+			handleRatingCallback(bot, callback)
+		} else if strings.HasPrefix(callback.Data, "list_") {
+			if isManagerUser(callback.From) {
+				handleTicketListCallback(bot, chatID, callback.Message.MessageID, callback.Data)
+			}
+		} else if strings.HasPrefix(callback.Data, "filter_") {
+			if isManagerUser(callback.From) {
+				handleTicketFilterCallback(bot, chatID, callback.Data)
+			}
+		} else if strings.HasPrefix(callback.Data, "client_ticket_select_") {
+			handleClientTicketSelect(bot, chatID, callback.Data)
+		} else if strings.HasPrefix(callback.Data, "client_ticket_keep_") {
+			handleClientKeepTicket(bot, chatID, callback.Data)
 		} else if strings.HasPrefix(callback.Data, "client_ticket_dialog_") {
 			ticketIDStr := strings.TrimPrefix(callback.Data, "client_ticket_dialog_")
 			ticketID, err := strconv.Atoi(ticketIDStr)
@@ -478,8 +606,13 @@ func handleCallbackQuery(bot *tgbotapi.BotAPI, callback *tgbotapi.CallbackQuery)
 // Функция для запуска опроса о товарах
 func startSurvey(bot *tgbotapi.BotAPI, chatID int64) {
 	log.Printf("Начинаю опрос для чата %d", chatID)
-	userStates[chatID] = &UserState{Step: 1}
+	state := &UserState{Step: stepProduct}
+	userStates[chatID] = state
+	sendProductChoice(bot, chatID, state)
+}
 
+// sendProductChoice отправляет карточки товаров для выбора в опросе
+func sendProductChoice(bot *tgbotapi.BotAPI, chatID int64, state *UserState) {
 	msg := tgbotapi.NewMessage(chatID, "Выберите интересующий мерч:")
 	if _, err := bot.Send(msg); err != nil {
 		log.Printf("Ошибка отправки сообщения: %v", err)
@@ -509,6 +642,16 @@ func startSurvey(bot *tgbotapi.BotAPI, chatID int64) {
 			}
 		}
 	}
+
+	// Навигация: возврат к сводке при правке или в главное меню
+	navMsg := tgbotapi.NewMessage(chatID, "Нажмите «Выбрать» под нужным товаром или подберите размер сразу для всего каталога")
+	navMsg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🧾 Все товары", "tee_"+surveyAllProducts),
+		),
+		surveyNavRow(state),
+	)
+	bot.Send(navMsg)
 }
 
 // Функция для обработки ответов в опросе
@@ -516,55 +659,51 @@ func handleSurveyResponse(bot *tgbotapi.BotAPI, message *tgbotapi.Message, state
 	chatID := message.Chat.ID
 
 	switch state.Step {
-	case 2:
-		height, err := strconv.Atoi(message.Text)
-		if err != nil {
-			msg := tgbotapi.NewMessage(chatID, "Пожалуйста, введите рост в сантиметрах (например: 175)")
-			bot.Send(msg)
+	case stepProduct:
+		bot.Send(tgbotapi.NewMessage(chatID, "Пожалуйста, выберите товар кнопкой «Выбрать»"))
+
+	case stepMeasure:
+		steps := surveyMeasurementsFor(state)
+		if state.MeasureIdx >= len(steps) {
+			showSurveySummary(bot, chatID, state)
 			return
 		}
-
-		if height < 100 || height > 250 {
-			msg := tgbotapi.NewMessage(chatID, "Рост должен быть от 100 до 250 см. Попробуйте еще раз:")
-			bot.Send(msg)
+		step := steps[state.MeasureIdx]
+		value, err := strconv.Atoi(strings.TrimSpace(message.Text))
+		if state.ItemMode && step.FromItem != nil {
+			// Замер по своей вещи переводим в замер тела
+			if err != nil || value < step.FromItem.Min || value > step.FromItem.Max {
+				msg := tgbotapi.NewMessage(chatID, step.FromItem.Retry)
+				bot.Send(msg)
+				return
+			}
+			value = step.FromItem.Convert(value)
+			if value < step.Min || value > step.Max {
+				bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("По вашей вещи получилось %d см — это вне допустимого диапазона (%d–%d см). Проверьте замер или введите замер тела.", value, step.Min, step.Max)))
+				return
+			}
+			bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("По вашей вещи: %s ≈ %d см", strings.ToLower(step.Name), value)))
+			setSurveyValue(state, step.Key, value)
+			advanceSurvey(bot, chatID, state)
 			return
 		}
-
-		state.Height = height
-		state.Step = 3
-		msg := tgbotapi.NewMessage(chatID, "Обхват груди? (в см)")
-		bot.Send(msg)
-
-	case 3:
-		chestSize, err := strconv.Atoi(message.Text)
 		if err != nil {
-			msg := tgbotapi.NewMessage(chatID, "Пожалуйста, введите обхват груди в сантиметрах (например: 90)")
+			msg := tgbotapi.NewMessage(chatID, step.Retry)
 			bot.Send(msg)
 			return
 		}
 
-		if chestSize < 70 || chestSize > 130 {
-			msg := tgbotapi.NewMessage(chatID, "Обхват груди должен быть от 70 до 130 см. Попробуйте еще раз:")
+		if value < step.Min || value > step.Max {
+			msg := tgbotapi.NewMessage(chatID, step.OutOfRange)
 			bot.Send(msg)
 			return
 		}
 
-		state.ChestSize = chestSize
-		// Оверсайз доступен только для модели "Крылатые Фразы" (индекс 0)
-		selectedIdx, _ := strconv.Atoi(state.SelectedTee)
-		if selectedIdx == 0 { // разрешаем оверсайз
-			state.Step = 4
-			askOversizeQuestion(bot, chatID)
-			return
-		}
-		// Для других моделей оверсайз недоступен: принудительно false и сразу показываем рекомендации
-		state.Oversize = false
-		showRecommendations(bot, chatID, state)
-		delete(userStates, chatID)
-		return
+		setSurveyValue(state, step.Key, value)
+		advanceSurvey(bot, chatID, state)
 
-	case 4:
-		response := strings.ToLower(message.Text)
+	case stepOversize:
+		response := strings.ToLower(strings.TrimSpace(message.Text))
 		switch response {
 		case "да", "yes":
 			state.Oversize = true
@@ -575,13 +714,16 @@ func handleSurveyResponse(bot *tgbotapi.BotAPI, message *tgbotapi.Message, state
 			bot.Send(msg)
 			return
 		}
-		showRecommendations(bot, chatID, state)
-		delete(userStates, chatID)
+		state.OversizeAnswered = true
+		advanceSurvey(bot, chatID, state)
+
+	case stepSummary:
+		showSurveySummary(bot, chatID, state)
 	}
 }
 
 // Функция для вопроса об оверсайзе
-func askOversizeQuestion(bot *tgbotapi.BotAPI, chatID int64) {
+func askOversizeQuestion(bot *tgbotapi.BotAPI, chatID int64, state *UserState) {
 	msg := tgbotapi.NewMessage(chatID, "Хотите ли вы оверсайз модель?")
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
@@ -589,6 +731,7 @@ func askOversizeQuestion(bot *tgbotapi.BotAPI, chatID int64) {
 			tgbotapi.NewInlineKeyboardButtonData("Да", "oversize_yes"),
 			tgbotapi.NewInlineKeyboardButtonData("Нет", "oversize_no"),
 		),
+		surveyNavRow(state),
 	)
 
 	msg.ReplyMarkup = keyboard
@@ -598,13 +741,13 @@ func askOversizeQuestion(bot *tgbotapi.BotAPI, chatID int64) {
 // Функция для обработки ответа на вопрос об оверсайзе
 func handleOversizeCallback(bot *tgbotapi.BotAPI, chatID int64, oversize bool) {
 	state, exists := userStates[chatID]
-	if !exists {
+	if !exists || state.Step != stepOversize {
 		return
 	}
 
 	state.Oversize = oversize
-	showRecommendations(bot, chatID, state)
-	delete(userStates, chatID)
+	state.OversizeAnswered = true
+	advanceSurvey(bot, chatID, state)
 }
 
 // Функция для показа рекомендаций размера
@@ -619,6 +762,11 @@ func showRecommendations(bot *tgbotapi.BotAPI, chatID int64, state *UserState) {
 		return
 	}
 
+	if state.SelectedTee == surveyAllProducts {
+		showAllProductsRecommendations(bot, chatID, state)
+		return
+	}
+
 	teeIndex, err := strconv.Atoi(state.SelectedTee)
 	if err != nil {
 		log.Printf("Ошибка парсинга индекса товара: %v", err)
@@ -636,10 +784,10 @@ func showRecommendations(bot *tgbotapi.BotAPI, chatID int64, state *UserState) {
 
 	product := products[teeIndex]
 
-	// Оверсайз только для модели "Крылатые Фразы" (индекс 0)
-	selectedIdx, _ := strconv.Atoi(state.SelectedTee)
-	oversize := state.Oversize && selectedIdx == 0
-	mark, ru := getSizeInfo(state.ChestSize, oversize)
+	// Оверсайз только для моделей, где он доступен
+	oversize := state.Oversize && productAllowsOversize(teeIndex)
+	rec := recommendForProduct(teeIndex, state)
+	mark, ru := rec.Mark, rec.RU
 
 	heightInfo := ""
 	if state.Height > 0 {
@@ -654,8 +802,11 @@ func showRecommendations(bot *tgbotapi.BotAPI, chatID int64, state *UserState) {
 		}
 	}
 
-	responseText := fmt.Sprintf("Вам подойдут следующие размеры модели:\n\n%s\nМаркировка: %s\nРоссийский размер: %s%s",
-		product.Name, mark, ru, heightInfo)
+	responseText := fmt.Sprintf("Вам подойдут следующие размеры модели:\n\n%s\nМаркировка: %s\nРоссийский размер: %s%s\n\n🎯 Точность подбора: %s",
+		product.Name, mark, ru, heightInfo, sizeConfidenceText(rec.Confidence))
+	if rec.Confidence != sizeConfidenceHigh {
+		responseText += fmt.Sprintf("\n⚠️ %s. Рекомендуем уточнить размер у менеджера.", rec.Reason)
+	}
 
 	msg := tgbotapi.NewMessage(chatID, responseText)
 
@@ -669,69 +820,107 @@ func showRecommendations(bot *tgbotapi.BotAPI, chatID int64, state *UserState) {
 			tgbotapi.NewInlineKeyboardButtonURL("Купить на сайте", product.Link),
 			tgbotapi.NewInlineKeyboardButtonURL("Весь каталог", "https://osteomerch.com/katalog/"),
 		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🧾 Размеры для всех товаров", "size_all_saved"),
+		),
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("Связаться с менеджером", "contact_manager"),
 		),
 	)
+	if rec.Confidence != sizeConfidenceHigh {
+		keyboard.InlineKeyboard = append([][]tgbotapi.InlineKeyboardButton{
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("💬 Уточнить у менеджера", "size_clarify"),
+			),
+		}, keyboard.InlineKeyboard...)
+	}
 
 	msg.ReplyMarkup = keyboard
 	if _, err := bot.Send(msg); err != nil {
 		log.Printf("Ошибка отправки рекомендаций: %v", err)
 	}
 
-	// Пробуем записать данные в активный тикет пользователя (если есть)
+	rememberMeasurements(chatID, state)
+	saveSurveyToTicket(chatID, state, oversize, mark)
+	recordClientSurvey(chatID, state, oversize, mark)
+}
+
+// saveSurveyToTicket записывает данные подбора в активный тикет пользователя (если есть)
+func saveSurveyToTicket(chatID int64, state *UserState, oversize bool, mark string) {
 	if ticketID, exists := userTickets[chatID]; exists {
 		if t, ok := tickets[ticketID]; ok {
 			t.Height = state.Height
 			t.ChestSize = state.ChestSize
 			t.Oversize = oversize
-			mark, _ := getSizeInfo(state.ChestSize, oversize)
 			t.RecommendedSize = mark
+			t.Product = surveyProductName(state)
+			t.Measurements = extraMeasurements(state)
 			t.LastMessage = time.Now()
 			saveTickets()
 		}
 	}
 }
 
+// Уровни точности подбора размера
+const (
+	sizeConfidenceHigh   = "high"
+	sizeConfidenceMedium = "medium" // обхват на границе двух размеров
+	sizeConfidenceLow    = "low"    // обхват вне таблицы или оверсайз недоступен
+)
+
+// sizeBorderlineMargin — расстояние (см) до границы строки таблицы, при котором подбор считается пограничным
+const sizeBorderlineMargin = 1
+
+// sizeRecommendation — результат подбора по размерной таблице
+type sizeRecommendation struct {
+	Mark       string
+	RU         string
+	Confidence string
+	Reason     string // почему подбор неточный (пусто при высокой точности)
+}
+
 // getSizeInfo возвращает маркировку и российский размер по таблице, учитывая оверсайз
 func getSizeInfo(chestSize int, oversize bool) (string, string) {
-	type row struct{ mark, ru string }
-	table := []struct {
-		min  int
-		max  int
-		size row
-	}{
-		{82, 89, row{"XS-S", "42-44"}},
-		{90, 97, row{"M-L", "46-48"}},
-		{98, 105, row{"XL-2XL", "50-52"}},
-		{106, 113, row{"3XL-4XL", "54-56"}},
-		{114, 121, row{"5XL-6XL", "58-60"}},
-	}
-	idx := -1
-	for i, r := range table {
-		if chestSize >= r.min && chestSize <= r.max {
-			idx = i
-			break
-		}
-	}
-	if idx == -1 {
-		if chestSize < table[0].min {
-			idx = 0
-		} else if chestSize > table[len(table)-1].max {
-			idx = len(table) - 1
-		}
-	}
-	if oversize && idx < len(table)-1 {
-		idx++
+	rec := recommendSize(chestSize, oversize)
+	return rec.Mark, rec.RU
+}
+
+// recommendSize подбирает размер по обхвату груди и оценивает точность подбора
+func recommendSize(chestSize int, oversize bool) sizeRecommendation {
+	return lookupSizeTable(topSizeTable, chestSize, "Обхват груди", oversize)
+}
+
+// sizeConfidenceText возвращает текстовое представление точности подбора
+func sizeConfidenceText(confidence string) string {
+	switch confidence {
+	case sizeConfidenceHigh:
+		return "🟢 высокая"
+	case sizeConfidenceMedium:
+		return "🟡 средняя"
+	case sizeConfidenceLow:
+		return "🔴 низкая"
+	default:
+		return confidence
 	}
-	return table[idx].size.mark, table[idx].size.ru
 }
 
 // isWithinBusinessHours проверяет, попадает ли текущее локальное время в 09:00-20:00
 func isWithinBusinessHours() bool {
-	now := time.Now()
-	h := now.Hour()
-	return h >= 9 && h < 20
+	return isBusinessTime(time.Now())
+}
+
+// envInt читает целое неотрицательное значение из переменной окружения (def — если не задано или некорректно)
+func envInt(name string, def int) int {
+	v := strings.TrimSpace(os.Getenv(name))
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		log.Printf("Некорректный %s: %s", name, v)
+		return def
+	}
+	return n
 }
 
 // Функция для показа каталога товаров
@@ -853,11 +1042,14 @@ func showClientTicketInterface(bot *tgbotapi.BotAPI, chatID int64) {
 			text += fmt.Sprintf("%s (%s):\n%s\n\n",
 				senderType,
 				msg.Time.Format("02.01 15:04"),
-				msg.Text)
+				messageDisplayText(msg))
 		}
 	} else {
 		text = fmt.Sprintf("🎫 Ваш тикет #%d (%s)\n\nСообщений пока нет", ticket.ID, getStatusText(ticket.Status))
 	}
+	if open := countOpenClientTickets(chatID); open > 1 {
+		text += fmt.Sprintf("\n\n📂 Открытых обращений: %d. Сообщения отправляются в тикет #%d — переключиться можно в «Мои обращения».", open, ticket.ID)
+	}
 
 	msg := tgbotapi.NewMessage(chatID, text)
 
@@ -871,9 +1063,12 @@ func showClientTicketInterface(bot *tgbotapi.BotAPI, chatID int64) {
 	})
 
 	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("🏠 Главная", "back_to_menu"),
+		tgbotapi.NewInlineKeyboardButtonData("📂 Мои обращения", "client_tickets"),
 		tgbotapi.NewInlineKeyboardButtonData("➕ Новый тикет", "create_new_ticket"),
 	})
+	keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("🏠 Главная", "back_to_menu"),
+	})
 
 	msg.ReplyMarkup = keyboard
 	bot.Send(msg)
@@ -1025,28 +1220,16 @@ func handleClientTicketMessage(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 		return
 	}
 
-	// Добавляем сообщение клиента в тикет
-	addMessageToTicket(ticketID, chatID, message.Text, false)
-
-	// Обновляем данные пользователя в тикете
-	updateTicketUserInfo(ticketID, message.From.UserName, message.From.FirstName, message.From.LastName)
-
-	// Отправляем сообщение менеджеру
-	messageText := fmt.Sprintf("💬 Новое сообщение от клиента (тикет #%d):\n\n%s", ticketID, message.Text)
-
-	// Рассылаем всем менеджерам
-	ids := getManagerIDs()
-	if len(ids) == 0 {
-		messageModeStates[chatID] = false
-		msg := tgbotapi.NewMessage(chatID, "✅ Сообщение сохранено в тикете!\n\n⚠️ Менеджеры не заданы - уведомление не отправлено.")
-		bot.Send(msg)
-		showClientTicketInterface(bot, chatID)
+	if !hasTicketContent(message) {
+		bot.Send(tgbotapi.NewMessage(chatID, unsupportedContentText))
 		return
 	}
-	for _, mid := range ids {
-		msg := tgbotapi.NewMessage(mid, messageText)
-		bot.Send(msg)
-	}
+	text := messageContent(message)
+	attachments := messageAttachments(message)
+
+	// Обновляем данные пользователя в тикете до карточки для менеджеров
+	updateTicketUserInfo(ticketID, message.From.UserName, message.From.FirstName, message.From.LastName)
+	postClientMessage(bot, ticket, message, text, attachments)
 
 	// Выключаем режим написания сообщения
 	messageModeStates[chatID] = false
@@ -1066,38 +1249,10 @@ func handleClientTicketMessage(bot *tgbotapi.BotAPI, message *tgbotapi.Message)
 
 // createNewClientTicket создает новый тикет для клиента
 func createNewClientTicket(bot *tgbotapi.BotAPI, chatID int64) {
-	// Очищаем текущий тикет из userTickets
-	if ticketID, exists := userTickets[chatID]; exists {
-		if ticket, found := tickets[ticketID]; found {
-			ticket.Status = "closed"
-			saveTickets()
-			log.Printf("Закрыт предыдущий тикет #%d для пользователя %d", ticketID, chatID)
-		}
-	}
-	delete(userTickets, chatID)
-
-	// Создаем новый тикет без данных подбора размера
-	now := time.Now()
-	ticket := &Ticket{
-		ID:          nextTicketID,
-		UserID:      chatID,
-		Username:    "",
-		FirstName:   "",
-		LastName:    "",
-		Height:      0,
-		ChestSize:   0,
-		Oversize:    false,
-		Status:      "open",
-		CreatedAt:   now,
-		LastMessage: now,
-		Messages:    []Message{},
-	}
-
-	tickets[nextTicketID] = ticket
-	userTickets[chatID] = nextTicketID
-	nextTicketID++
-
-	saveTickets()
+	// Новый тикет без данных подбора размера; предыдущие тикеты остаются открытыми, новый становится выбранным
+	ticket := newClientTicket(chatID, nil, "")
+	// Следующее сообщение клиента попадет в новый тикет
+	questionStates[chatID] = true
 
 	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Создан новый тикет #%d!\n\n💬 Напишите ваше первое сообщение менеджеру в этом чате.", ticket.ID))
 
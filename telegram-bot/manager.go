@@ -21,7 +21,18 @@ func sendManagerMenu(bot *tgbotapi.BotAPI, chatID int64) {
 		}
 	}
 
-	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("👨‍💼 Добро пожаловать, менеджер!\n\n📊 Тикеты: 🟢 %d открытых | 🔴 %d закрытых\n\nВыберите действие:", openTickets, closedTickets))
+	shiftText := "🟢 Вы на смене"
+	shiftButton := "⚪ Уйти со смены"
+	if !isOnShift(chatID) {
+		shiftText = "⚪ Вы не на смене — новые тикеты вам не назначаются"
+		shiftButton = "🟢 Выйти на смену"
+	}
+
+	if sessionText := managerSessionText(chatID); sessionText != "" {
+		shiftText += "\n" + sessionText
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("👨‍💼 Добро пожаловать, менеджер!\n\n📊 Тикеты: 🟢 %d открытых | 🔴 %d закрытых\n%s\n\nВыберите действие:", openTickets, closedTickets, shiftText))
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
@@ -33,10 +44,22 @@ func sendManagerMenu(bot *tgbotapi.BotAPI, chatID int64) {
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("📊 Статистика", "manager_export_menu"),
 		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("📝 Шаблоны", "manager_templates"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(shiftButton, "manager_toggle_shift"),
+		),
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("❓ Помощь", "help"),
 		),
 	)
+	if session, ok := managerSessions[chatID]; ok {
+		keyboard.InlineKeyboard = append(keyboard.InlineKeyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("👁 Тикет #%d", session.TicketID), fmt.Sprintf("ticket_view_%d", session.TicketID)),
+			tgbotapi.NewInlineKeyboardButtonData("🚪 Выйти из диалога", "manager_session_exit"),
+		))
+	}
 
 	msg.ReplyMarkup = keyboard
 	bot.Send(msg)
@@ -67,12 +90,16 @@ func handleManagerStatsCallback(bot *tgbotapi.BotAPI, chatID int64) {
 		}
 	}
 
+	reminders, escalations, breached := slaStats()
+
 	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("📊 Статистика тикетов:\n\n"+
 		"📈 Всего тикетов: %d\n"+
 		"🟢 Открытых: %d\n"+
 		"🔴 Закрытых: %d\n"+
-		"📅 Последний ID: %d",
-		totalTickets, openTickets, closedTickets, nextTicketID-1))
+		"📅 Последний ID: %d\n\n"+
+		"⏰ Нарушения SLA: %d тикетов (напоминаний: %d, эскалаций: %d)\n\n%s",
+		totalTickets, openTickets, closedTickets, nextTicketID-1,
+		breached, reminders, escalations, ratingStatsText()))
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
@@ -90,6 +117,13 @@ func handleManagerHelpCallback(bot *tgbotapi.BotAPI, chatID int64) {
 		"• Новые тикеты - показать количество открытых\n"+
 		"• Статистика - общая статистика\n"+
 		"• Помощь - эта справка\n\n"+
+		"💬 Ответ в тикет:\n"+
+		"• «Ответить» - следующее сообщение уйдет клиенту\n"+
+		"• «Закрепить диалог» - все сообщения уходят в тикет до выхода (/done)\n"+
+		"• Исправленный ответ обновится у клиента, «↩️ Отозвать» или /recall (reply на ответ) удалит его\n\n"+
+		"🗒 Заметки (видны только менеджерам):\n"+
+		"• /note <ID тикета> <текст>\n"+
+		"• /note <текст> - в активный тикет или в ответ на уведомление тикета\n\n"+
 		"💡 Все действия выполняются через кнопки для удобства управления")
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
@@ -131,12 +165,23 @@ func clearClientStates(chatID int64) {
 	delete(messageModeStates, chatID)
 	delete(searchState, chatID)
 	delete(exportTicketIDState, chatID)
+	delete(tagInputState, chatID)
+	delete(contactCategoryState, chatID)
+	delete(templateInputState, chatID)
+	delete(templateDraftState, chatID)
+	delete(noteInputState, chatID)
+	delete(ratingCommentState, chatID)
+	delete(transferState, chatID)
+	delete(splitInputState, chatID)
 }
 
-// Очищает состояния менеджера и разрывает режим ответа (удаляет userTickets для чата менеджера)
+// Очищает состояния менеджера и отменяет ответ одним сообщением. Закрепленный диалог сохраняется
+// (он виден в меню менеджера), привязку userTickets не трогаем — менеджер может быть и клиентом
 func clearManagerStates(chatID int64) {
 	clearClientStates(chatID)
-	delete(userTickets, chatID)
+	if session, ok := managerSessions[chatID]; ok && !session.Sticky {
+		endManagerSession(chatID)
+	}
 }
 
 func isManagerResponse(message *tgbotapi.Message) bool {
@@ -146,9 +191,13 @@ func isManagerResponse(message *tgbotapi.Message) bool {
 func handleManagerResponse(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	text := message.Text
 
-	// Проверяем, находится ли менеджер в режиме ответа на тикет
-	if ticketID, exists := userTickets[message.Chat.ID]; exists {
-		handleManagerReplyToTicket(bot, message, ticketID)
+	// Ответ (reply) на уведомление по тикету направляем в этот тикет
+	if handleManagerThreadReply(bot, message) {
+		return
+	}
+
+	// Проверяем, есть ли у менеджера активный тикет
+	if handleManagerSessionMessage(bot, message) {
 		return
 	}
 
@@ -175,7 +224,17 @@ func handleOldReplyFormat(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 
 		answerText := parts[2]
 		responseMsg := tgbotapi.NewMessage(userID, fmt.Sprintf("💬 Ответ от менеджера:\n\n%s", answerText))
-		bot.Send(responseMsg)
+		// Если у пользователя есть тикет, недоставку отмечаем в нем, как и для ответов из тикета
+		if ticket, ok := tickets[userTickets[userID]]; ok && ticket.UserID == userID {
+			_, err = sendToClient(bot, ticket, responseMsg, message.Chat.ID)
+		} else {
+			_, err = bot.Send(responseMsg)
+		}
+		if err != nil {
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("⚠️ Ответ не доставлен пользователю %d: %s", userID, deliveryErrorText(err))))
+			log.Printf("Ответ менеджера пользователю %d не доставлен: %v", userID, err)
+			return
+		}
 
 		confirmMsg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("✅ Ответ отправлен пользователю %d", userID))
 		bot.Send(confirmMsg)
@@ -220,9 +279,13 @@ func showManagersList(bot *tgbotapi.BotAPI, chatID int64) {
 		return
 	}
 	var b strings.Builder
-	b.WriteString("Текущие менеджеры:\n")
+	b.WriteString(fmt.Sprintf("Текущие менеджеры (распределение: %s):\n", routingStrategyText(ticketRoutingStrategy())))
 	for _, id := range ids {
-		b.WriteString(fmt.Sprintf("• ID: %d\n", id))
+		shift := "🟢"
+		if !isOnShift(id) {
+			shift = "⚪"
+		}
+		b.WriteString(fmt.Sprintf("• %s ID: %d\n", shift, id))
 	}
 	for u := range managerUsernamesSet {
 		b.WriteString(fmt.Sprintf("• @%s (по username)\n", u))
@@ -325,144 +388,26 @@ func idFmt(id int64) string {
 	return fmt.Sprintf("(ID %d)", id)
 }
 
-// showTicketsWithFilters показывает тикеты с фильтрацией и поиском
+// showTicketsWithFilters показывает первую страницу тикетов с фильтрацией и поиском.
+// Статус задается кнопками списка, остальные фильтры и сортировка сохраняются.
 func showTicketsWithFilters(bot *tgbotapi.BotAPI, chatID int64, statusFilter ...string) {
-	var filteredTickets []*Ticket
-	var title string
-
-	// Фильтрация по статусу
-	if len(statusFilter) > 0 && statusFilter[0] != "" {
-		for _, ticket := range tickets {
-			if ticket.Status == statusFilter[0] {
-				filteredTickets = append(filteredTickets, ticket)
-			}
-		}
-		switch statusFilter[0] {
-		case "open":
-			title = "🆕 Открытые тикеты"
-		case "closed":
-			title = "🔴 Закрытые тикеты"
-		default:
-			title = "🎫 Тикеты"
-		}
-	} else {
-		for _, ticket := range tickets {
-			filteredTickets = append(filteredTickets, ticket)
-		}
-		title = "🎫 Все тикеты"
-	}
-
-	// Сортировка по ID (новые сверху)
-	for i := 0; i < len(filteredTickets)-1; i++ {
-		for j := i + 1; j < len(filteredTickets); j++ {
-			if filteredTickets[i].ID < filteredTickets[j].ID {
-				filteredTickets[i], filteredTickets[j] = filteredTickets[j], filteredTickets[i]
-			}
-		}
-	}
-
-	// Формируем сообщение
-	var text strings.Builder
-	text.WriteString(fmt.Sprintf("%s (%d):\n\n", title, len(filteredTickets)))
-
-	if len(filteredTickets) == 0 {
-		text.WriteString("📭 Нет тикетов")
-	} else {
-		// Показываем первые 10 тикетов с подробной информацией
-		limit := 10
-		if len(filteredTickets) < limit {
-			limit = len(filteredTickets)
-		}
-
-		for i := 0; i < limit; i++ {
-			ticket := filteredTickets[i]
-			status := "🟢"
-			if ticket.Status == "closed" {
-				status = "🔴"
-			}
-
-			// Формируем имя пользователя
-			name := strings.TrimSpace(ticket.FirstName + " " + ticket.LastName)
-			if name == "" {
-				name = "Без имени"
-			}
-
-			// Формируем username
-			username := ""
-			if ticket.Username != "" {
-				username = fmt.Sprintf(" (@%s)", ticket.Username)
-			}
-
-			// Время создания
-			timeStr := ticket.CreatedAt.Format("02.01 15:04")
-
-			text.WriteString(fmt.Sprintf("%s #%d %s%s\n🆔 %d | %s\n\n",
-				status, ticket.ID, name, username, ticket.UserID, timeStr))
-		}
-
-		if len(filteredTickets) > 10 {
-			text.WriteString(fmt.Sprintf("... и еще %d тикетов", len(filteredTickets)-10))
-		}
+	filter := ticketListFilterFor(chatID)
+	filter.Status = ""
+	if len(statusFilter) > 0 {
+		filter.Status = statusFilter[0]
 	}
+	filter.Page = 0
 
-	msg := tgbotapi.NewMessage(chatID, text.String())
-
-	// Создаем кнопки
-	var keyboard [][]tgbotapi.InlineKeyboardButton
-
-	// Кнопки фильтров
-	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("🎫 Все", "manager_tickets"),
-		tgbotapi.NewInlineKeyboardButtonData("🆕 Открытые", "manager_open_tickets"),
-		tgbotapi.NewInlineKeyboardButtonData("🔴 Закрытые", "manager_closed_tickets"),
-	})
-
-	// Кнопка поиска
-	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("🔍 Поиск по номеру", "manager_search_ticket"),
-	})
-
-	// Кнопки тикетов (максимум 5 в ряд)
-	buttonLimit := 10
-	if len(filteredTickets) < buttonLimit {
-		buttonLimit = len(filteredTickets)
-	}
-	for i := 0; i < buttonLimit && i < len(filteredTickets); i++ {
-		ticketID := filteredTickets[i].ID
-		ticket := filteredTickets[i]
-
-		buttonText := fmt.Sprintf("#%d", ticketID)
-		if len(ticket.FirstName) > 0 {
-			shortName := ticket.FirstName
-			if len(shortName) > 8 {
-				shortName = shortName[:8] + "..."
-			}
-			buttonText = fmt.Sprintf("#%d %s", ticketID, shortName)
-		}
-
-		button := tgbotapi.NewInlineKeyboardButtonData(buttonText, fmt.Sprintf("ticket_view_%d", ticketID))
-
-		// Добавляем кнопку в ряд
-		if len(keyboard) == 0 || len(keyboard[len(keyboard)-1]) >= 2 {
-			keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{button})
-		} else {
-			keyboard[len(keyboard)-1] = append(keyboard[len(keyboard)-1], button)
-		}
-	}
-
-	// Кнопка "Назад"
-	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back_to_manager_menu"),
-	})
-
-	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	text, keyboard := renderTicketList(chatID)
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = keyboard
 	bot.Send(msg)
 }
 
-// handleManagerSearchTicket обрабатывает поиск тикета по номеру
+// handleManagerSearchTicket запрашивает у менеджера поисковый запрос
 func handleManagerSearchTicket(bot *tgbotapi.BotAPI, chatID int64) {
 	searchState[chatID] = true
-	msg := tgbotapi.NewMessage(chatID, "🔍 Введите номер тикета для поиска:\n\nИспользуйте /cancel для отмены")
+	msg := tgbotapi.NewMessage(chatID, searchHelpText)
 
 	keyboard := tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
@@ -473,37 +418,47 @@ func handleManagerSearchTicket(bot *tgbotapi.BotAPI, chatID int64) {
 	bot.Send(msg)
 }
 
-// handleTicketSearchInput обрабатывает ввод номера тикета для поиска
+// handleTicketSearchInput обрабатывает поисковый запрос: номер тикета открывает его сразу,
+// остальное становится условием списка тикетов вместе с текущими фильтрами
 func handleTicketSearchInput(bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
 	chatID := message.Chat.ID
 	if !searchState[chatID] {
 		return false
 	}
 
-	if message.Text == "/cancel" {
+	query := strings.TrimSpace(message.Text)
+	if query == "/cancel" {
 		delete(searchState, chatID)
 		showTicketsWithFilters(bot, chatID)
 		return true
 	}
-
-	// Парсим номер тикета
-	ticketID, err := strconv.Atoi(strings.TrimSpace(message.Text))
-	if err != nil {
-		bot.Send(tgbotapi.NewMessage(chatID, "❌ Неверный формат. Введите числовой номер тикета или /cancel"))
+	if query == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Введите текст запроса или /cancel"))
 		return true
 	}
 
-	// Ищем тикет
-	_, exists := tickets[ticketID]
-	if !exists {
-		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Тикет #%d не найден", ticketID)))
-		delete(searchState, chatID)
+	// Номер существующего тикета — показываем его
+	if ticketID, err := strconv.Atoi(query); err == nil {
+		if _, exists := tickets[ticketID]; exists {
+			delete(searchState, chatID)
+			showTicketDetails(bot, chatID, ticketID)
+			return true
+		}
+	}
+
+	filter := ticketListFilterFor(chatID)
+	next := ticketListFilter{Category: filter.Category, Priority: filter.Priority, Tag: filter.Tag, Assignee: filter.Assignee, Sort: filter.Sort}
+	search, err := parseTicketSearch(query, &next)
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ %s\n\nИсправьте запрос или используйте /cancel", err)))
 		return true
 	}
+	next.Search = search
+	*filter = next
 
-	// Показываем найденный тикет
 	delete(searchState, chatID)
-	showTicketDetails(bot, chatID, ticketID)
+	log.Printf("Менеджер %d ищет тикеты: %s", chatID, query)
+	showTicketsWithFilters(bot, chatID, next.Status)
 	return true
 }
 
@@ -2,6 +2,7 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"strconv"
@@ -14,12 +15,16 @@ var managerIDsSet = make(map[int64]bool)
 var managerUsernamesSet = make(map[string]bool)
 var adminIDsSet = make(map[int64]bool)
 var adminUsernamesSet = make(map[string]bool)
+var managerNames = make(map[int64]string) // ID менеджера -> отображаемое имя (@username или имя)
+var offShiftSet = make(map[int64]bool)    // менеджеры, отметившиеся как не на смене
 
 const managersStoreFile = "managers.json"
 
 type managersStore struct {
-	ManagerIDs       []int64  `json:"manager_ids"`
-	ManagerUsernames []string `json:"manager_usernames"`
+	ManagerIDs       []int64          `json:"manager_ids"`
+	ManagerUsernames []string         `json:"manager_usernames"`
+	ManagerNames     map[int64]string `json:"manager_names,omitempty"`
+	OffShift         []int64          `json:"off_shift,omitempty"`
 }
 
 // initManagers загружает список менеджеров из переменных окружения
@@ -176,6 +181,10 @@ func saveManagersToFile() {
 	for u := range managerUsernamesSet {
 		store.ManagerUsernames = append(store.ManagerUsernames, u)
 	}
+	store.ManagerNames = managerNames
+	for id := range offShiftSet {
+		store.OffShift = append(store.OffShift, id)
+	}
 	data, err := json.MarshalIndent(&store, "", "  ")
 	if err != nil {
 		log.Printf("Ошибка сериализации managers.json: %v", err)
@@ -205,6 +214,12 @@ func loadManagersFromFile() {
 		}
 		managerUsernamesSet[strings.ToLower(u)] = true
 	}
+	for id, name := range store.ManagerNames {
+		managerNames[id] = name
+	}
+	for _, id := range store.OffShift {
+		offShiftSet[id] = true
+	}
 }
 
 func addManagerByID(userID int64) {
@@ -216,3 +231,42 @@ func removeManagerByID(userID int64) {
 	delete(managerIDsSet, userID)
 	saveManagersToFile()
 }
+
+// rememberManagerName запоминает отображаемое имя менеджера (для назначения тикетов)
+func rememberManagerName(user *tgbotapi.User) {
+	if user == nil || !isManagerUser(user) {
+		return
+	}
+	name := strings.TrimSpace(user.FirstName + " " + user.LastName)
+	if user.UserName != "" {
+		name = "@" + user.UserName
+	}
+	if name == "" || managerNames[user.ID] == name {
+		return
+	}
+	managerNames[user.ID] = name
+	saveManagersToFile()
+}
+
+// managerDisplayName возвращает имя менеджера для отображения
+func managerDisplayName(id int64) string {
+	if name, ok := managerNames[id]; ok && name != "" {
+		return name
+	}
+	return fmt.Sprintf("ID %d", id)
+}
+
+// isOnShift проверяет, принимает ли менеджер новые тикеты
+func isOnShift(id int64) bool {
+	return !offShiftSet[id]
+}
+
+// setOnShift отмечает менеджера на смене или вне смены
+func setOnShift(id int64, onShift bool) {
+	if onShift {
+		delete(offShiftSet, id)
+	} else {
+		offShiftSet[id] = true
+	}
+	saveManagersToFile()
+}
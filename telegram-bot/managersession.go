@@ -0,0 +1,157 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// managerSession — активный тикет менеджера, в который уходят его сообщения.
+// Хранится отдельно от userTickets (привязки клиентов к тикетам), поэтому менеджер
+// может одновременно быть клиентом.
+type managerSession struct {
+	TicketID int
+	Sticky   bool // false — ответ одним сообщением, true — все сообщения в тикет до выхода
+}
+
+var managerSessions = make(map[int64]*managerSession) // chatID менеджера -> сессия
+
+// startManagerSession делает тикет активным для менеджера
+func startManagerSession(bot *tgbotapi.BotAPI, chatID int64, ticketID int, sticky bool) {
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	if ticket.Status != "open" {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Тикет #%d закрыт", ticketID)))
+		return
+	}
+
+	managerSessions[chatID] = &managerSession{TicketID: ticketID, Sticky: sticky}
+	log.Printf("Менеджер %d: активный тикет #%d (закреплен: %v)", chatID, ticketID, sticky)
+
+	text := fmt.Sprintf("💬 Ответ в тикет #%d\n\nНапишите ваш ответ клиенту:", ticketID)
+	if sticky {
+		text = fmt.Sprintf("📌 Диалог с тикетом #%d закреплен\n\nВсе ваши сообщения будут отправляться клиенту, пока вы не выйдете из диалога (/done).", ticketID)
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = managerSessionKeyboard(managerSessions[chatID])
+	bot.Send(msg)
+}
+
+// endManagerSession завершает сессию менеджера
+func endManagerSession(chatID int64) {
+	delete(managerSessions, chatID)
+}
+
+// managerSessionKeyboard — кнопки выхода из сессии (отмена ответа или выход из закрепленного диалога)
+func managerSessionKeyboard(session *managerSession) tgbotapi.InlineKeyboardMarkup {
+	if session.Sticky {
+		return tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("👁 Тикет", fmt.Sprintf("ticket_view_%d", session.TicketID)),
+				tgbotapi.NewInlineKeyboardButtonData("🚪 Выйти из диалога", "manager_session_exit"),
+			),
+		)
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("ticket_view_%d", session.TicketID)),
+		),
+	)
+}
+
+// stickyButton — кнопка закрепления диалога или выхода из него
+func stickyButton(ticketID int, sticky bool) tgbotapi.InlineKeyboardButton {
+	if sticky {
+		return tgbotapi.NewInlineKeyboardButtonData("🚪 Выйти из диалога", "manager_session_exit")
+	}
+	return tgbotapi.NewInlineKeyboardButtonData("📌 Закрепить диалог", fmt.Sprintf("ticket_sticky_%d", ticketID))
+}
+
+// managerSessionText — индикатор активного тикета для меню менеджера
+func managerSessionText(chatID int64) string {
+	session, ok := managerSessions[chatID]
+	if !ok {
+		return ""
+	}
+	if session.Sticky {
+		return fmt.Sprintf("📌 Закрепленный диалог: тикет #%d", session.TicketID)
+	}
+	return fmt.Sprintf("💬 Ожидается ответ в тикет #%d", session.TicketID)
+}
+
+// handleManagerSessionMessage отправляет сообщение менеджера в активный тикет.
+// Возвращает false, если активной сессии нет.
+func handleManagerSessionMessage(bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
+	chatID := message.Chat.ID
+	session, ok := managerSessions[chatID]
+	if !ok {
+		return false
+	}
+
+	switch strings.TrimSpace(message.Text) {
+	case "/done", "/cancel":
+		endManagerSession(chatID)
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Вы вышли из тикета #%d", session.TicketID)))
+		sendManagerMenu(bot, chatID)
+		return true
+	}
+
+	ticket, exists := tickets[session.TicketID]
+	if !exists || ticket.Status != "open" {
+		endManagerSession(chatID)
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Тикет #%d закрыт или не найден — сессия завершена", session.TicketID)))
+		return true
+	}
+
+	if sendManagerReply(bot, message, ticket, 0) {
+		if session.Sticky {
+			msg := tgbotapi.NewMessage(chatID, managerSessionText(chatID))
+			msg.ReplyMarkup = managerSessionKeyboard(session)
+			bot.Send(msg)
+		} else {
+			endManagerSession(chatID)
+		}
+	}
+	return true
+}
+
+// handleManagerSessionExit обрабатывает кнопку выхода из закрепленного диалога
+func handleManagerSessionExit(bot *tgbotapi.BotAPI, chatID int64) {
+	if session, ok := managerSessions[chatID]; ok {
+		endManagerSession(chatID)
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Вы вышли из тикета #%d", session.TicketID)))
+	}
+	sendManagerMenu(bot, chatID)
+}
+
+// endManagerSessionsForTicket завершает сессии всех менеджеров в закрытом тикете
+// и возвращает для них уведомления
+func endManagerSessionsForTicket(ticketID int) []outMessage {
+	var out []outMessage
+	for chatID, session := range managerSessions {
+		if session.TicketID == ticketID {
+			endManagerSession(chatID)
+			out = append(out, outMessage{Msg: tgbotapi.NewMessage(chatID, fmt.Sprintf("ℹ️ Тикет #%d закрыт — вы вышли из диалога", ticketID))})
+		}
+	}
+	return out
+}
+
+// managerHandlesMessage решает, обрабатывать ли сообщение менеджера как менеджерское.
+// Менеджер, который сам пишет в свой клиентский тикет, обслуживается как клиент,
+// если у него нет активной сессии и сообщение не является reply на уведомление тикета.
+func managerHandlesMessage(message *tgbotapi.Message) bool {
+	chatID := message.Chat.ID
+	if _, ok := managerSessions[chatID]; ok {
+		return true
+	}
+	if _, ok := lookupReplyTarget(message); ok {
+		return true
+	}
+	return !questionStates[chatID] && !messageModeStates[chatID]
+}
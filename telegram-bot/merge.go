@@ -0,0 +1,440 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+var splitInputState = make(map[int64]int) // chatID менеджера -> ID тикета, для которого вводится диапазон сообщений
+
+// handleTicketMergeCallback обрабатывает кнопки объединения тикетов:
+//
+//	ticket_merge_<ID> — выбор тикета того же клиента
+//	ticket_merge_pick_<ID>_<другой ID> — подтверждение
+//	ticket_merge_do_<ID>_<другой ID> — присоединить другой тикет к этому
+//
+// Разделение (ticket_split_<ID>) обрабатывается через ticketIDCallbacks.
+func handleTicketMergeCallback(bot *tgbotapi.BotAPI, chatID int64, data string) {
+	rest := strings.TrimPrefix(data, "ticket_merge_")
+	action := ""
+	for _, a := range []string{"pick_", "do_"} {
+		if strings.HasPrefix(rest, a) {
+			action, rest = strings.TrimSuffix(a, "_"), strings.TrimPrefix(rest, a)
+			break
+		}
+	}
+	var ids []int
+	for _, part := range strings.Split(rest, "_") {
+		id, err := strconv.Atoi(part)
+		if err != nil {
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Ошибка ID тикета"))
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	switch {
+	case action == "" && len(ids) == 1:
+		showMergeCandidates(bot, chatID, ids[0])
+	case action == "pick" && len(ids) == 2:
+		confirmMerge(bot, chatID, ids[0], ids[1])
+	case action == "do" && len(ids) == 2:
+		keep, ok1 := tickets[ids[0]]
+		absorb, ok2 := tickets[ids[1]]
+		if !ok1 || !ok2 {
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+			return
+		}
+		if err := mergeTickets(bot, keep, absorb, chatID); err != nil {
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ "+err.Error()))
+			return
+		}
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Тикет #%d присоединен к тикету #%d", absorb.ID, keep.ID)))
+		showTicketDetails(bot, chatID, keep.ID)
+	}
+}
+
+// mergeCandidates — другие тикеты того же клиента, которые можно присоединить
+func mergeCandidates(ticket *Ticket) []*Ticket {
+	var list []*Ticket
+	for _, t := range clientTicketList(ticket.UserID) {
+		if t.ID != ticket.ID {
+			list = append(list, t)
+		}
+	}
+	return list
+}
+
+// showMergeCandidates показывает тикеты клиента, которые можно присоединить к текущему
+func showMergeCandidates(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	candidates := mergeCandidates(ticket)
+	if len(candidates) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ У клиента нет других тикетов"))
+		return
+	}
+
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for i, t := range candidates {
+		if i == maxClientTicketsInList {
+			break
+		}
+		title := fmt.Sprintf("%s, %s, сообщений: %d", clientTicketTitle(t), t.CreatedAt.Format("02.01"), len(t.Messages))
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(title, fmt.Sprintf("ticket_merge_pick_%d_%d", ticketID, t.ID)),
+		))
+	}
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("ticket_view_%d", ticketID)),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🔗 Объединение тикетов\n\nВыберите тикет клиента, который нужно присоединить к тикету #%d. "+
+		"Сообщения будут объединены по времени, выбранный тикет закроется.", ticketID))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	bot.Send(msg)
+}
+
+// confirmMerge просит подтвердить объединение
+func confirmMerge(bot *tgbotapi.BotAPI, chatID int64, keepID, absorbID int) {
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🔗 Присоединить тикет #%d к тикету #%d?\n\n"+
+		"Все сообщения, заметки, теги и замеры перейдут в #%d, тикет #%d будет закрыт. Отменить объединение нельзя.",
+		absorbID, keepID, keepID, absorbID))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔗 Объединить", fmt.Sprintf("ticket_merge_do_%d_%d", keepID, absorbID)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("ticket_view_%d", keepID)),
+		),
+	)
+	bot.Send(msg)
+}
+
+// moveMessages переносит сообщения из тикета from в to: сообщения получают новые номера в to,
+// прежние сохраняются в Aliases. Возвращает перенесенные сообщения (добавление в to — на вызывающей стороне).
+func moveMessages(from, to *Ticket, messages []Message) []Message {
+	moved := make([]Message, len(messages))
+	for i, m := range messages {
+		m.Aliases = append(append([]MessageAlias(nil), m.Aliases...), MessageAlias{TicketID: from.ID, MessageID: m.ID})
+		m.ID = allocMessageID(to)
+		moved[i] = m
+	}
+	return moved
+}
+
+// priorityRank — позиция приоритета в ticketPriorities (больше — срочнее)
+func priorityRank(key string) int {
+	for i, p := range ticketPriorities {
+		if p.Key == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeTickets переносит в keep сообщения (по времени), заметки, историю и данные подбора из absorb.
+// absorb закрывается с отметкой MergedInto; клиента и менеджеров, работавших с absorb, переключаем на keep.
+func mergeTickets(bot *tgbotapi.BotAPI, keep, absorb *Ticket, actorID int64) error {
+	switch {
+	case keep.ID == absorb.ID:
+		return fmt.Errorf("нельзя объединить тикет с самим собой")
+	case keep.UserID != absorb.UserID:
+		return fmt.Errorf("объединять можно только тикеты одного клиента")
+	case keep.MergedInto != 0 || absorb.MergedInto != 0:
+		return fmt.Errorf("один из тикетов уже объединен с другим")
+	}
+
+	// Сообщения absorb получают новые номера в keep, а показываются по времени
+	keep.Messages = append(keep.Messages, moveMessages(absorb, keep, absorb.Messages)...)
+	sort.SliceStable(keep.Messages, func(i, j int) bool {
+		return keep.Messages[i].Time.Before(keep.Messages[j].Time)
+	})
+
+	keep.Notes = append(keep.Notes, absorb.Notes...)
+	sort.SliceStable(keep.Notes, func(i, j int) bool { return keep.Notes[i].Time.Before(keep.Notes[j].Time) })
+	for i := range keep.Notes {
+		keep.Notes[i].ID = i + 1
+	}
+	// Номера сообщений в истории absorb относятся к нему — помечаем, из какого тикета запись
+	for _, e := range absorb.Events {
+		e.Text = fmt.Sprintf("[#%d] %s", absorb.ID, e.Text)
+		keep.Events = append(keep.Events, e)
+	}
+	sort.SliceStable(keep.Events, func(i, j int) bool { return keep.Events[i].Time.Before(keep.Events[j].Time) })
+	keep.SLABreaches = append(keep.SLABreaches, absorb.SLABreaches...)
+	keep.Notifications = append(keep.Notifications, absorb.Notifications...)
+
+	// Данные подбора: недостающие значения берем из присоединяемого тикета
+	if keep.Height == 0 && keep.ChestSize == 0 && absorb.Height > 0 {
+		keep.Height, keep.ChestSize, keep.Oversize = absorb.Height, absorb.ChestSize, absorb.Oversize
+	}
+	if keep.RecommendedSize == "" || keep.RecommendedSize == "Не определен" {
+		if absorb.RecommendedSize != "" {
+			keep.RecommendedSize = absorb.RecommendedSize
+		}
+	}
+	if keep.Product == "" {
+		keep.Product = absorb.Product
+	}
+	for k, v := range absorb.Measurements {
+		if _, ok := keep.Measurements[k]; !ok {
+			if keep.Measurements == nil {
+				keep.Measurements = make(map[string]int)
+			}
+			keep.Measurements[k] = v
+		}
+	}
+	if keep.Question == "" {
+		keep.Question = absorb.Question
+	}
+
+	if keep.Category == "" {
+		keep.Category = absorb.Category
+	}
+	if priorityRank(ticketPriority(absorb)) > priorityRank(ticketPriority(keep)) {
+		keep.Priority = absorb.Priority
+	}
+	for _, tag := range absorb.Tags {
+		if !containsString(keep.Tags, tag) {
+			keep.Tags = append(keep.Tags, tag)
+		}
+	}
+	if keep.AssigneeID == 0 && absorb.AssigneeID != 0 {
+		setTicketAssignee(keep, absorb.AssigneeID)
+	}
+	if keep.Rating == nil {
+		keep.Rating = absorb.Rating
+	}
+	keep.Undeliverable = keep.Undeliverable || absorb.Undeliverable
+	if absorb.CreatedAt.Before(keep.CreatedAt) {
+		keep.CreatedAt = absorb.CreatedAt
+	}
+	if absorb.LastMessage.After(keep.LastMessage) {
+		keep.LastMessage = absorb.LastMessage
+	}
+
+	// Если клиент ждал ответа в присоединяемом тикете, объединенный тикет должен быть открыт
+	if absorb.Status == "open" && keep.Status != "open" {
+		keep.Status = "open"
+		keep.ClosedAt = time.Time{}
+		keep.CloseReason = ""
+		setTicketTopicClosed(bot, keep, false)
+	}
+
+	absorb.Messages = nil
+	absorb.Notes = nil
+	absorb.Notifications = nil
+	absorb.Status = "closed"
+	absorb.ClosedAt = time.Now()
+	absorb.CloseReason = "merged"
+	absorb.MergedInto = keep.ID
+	logTicketEvent(absorb, "merged", actorID, fmt.Sprintf("%s присоединил тикет к #%d", managerDisplayName(actorID), keep.ID))
+	logTicketEvent(keep, "merged", actorID, fmt.Sprintf("%s присоединил тикет #%d", managerDisplayName(actorID), absorb.ID))
+
+	// Клиент и менеджеры, работавшие с absorb, продолжают в keep
+	if userTickets[keep.UserID] == absorb.ID {
+		userTickets[keep.UserID] = keep.ID
+	}
+	for _, session := range managerSessions {
+		if session.TicketID == absorb.ID {
+			session.TicketID = keep.ID
+		}
+	}
+	if absorb.TopicID != 0 {
+		sendTopicMessage(bot, absorb, fmt.Sprintf("🔗 Тикет присоединен к тикету #%d, переписка продолжается в его теме", keep.ID))
+		setTicketTopicClosed(bot, absorb, true)
+	}
+
+	rebuildTelegramMessageIndex()
+	saveTickets()
+	log.Printf("Тикет #%d присоединен к тикету #%d менеджером %d", absorb.ID, keep.ID, actorID)
+
+	sendToClient(bot, keep, tgbotapi.NewMessage(keep.UserID, fmt.Sprintf(
+		"🔗 Ваши обращения #%d и #%d объединены в одно — #%d. История переписки сохранена.", keep.ID, absorb.ID, keep.ID)), actorID)
+	return nil
+}
+
+// startSplitInput показывает пронумерованные сообщения тикета и ждет диапазон для выделения
+func startSplitInput(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	if len(ticket.Messages) < 2 {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ В тикете слишком мало сообщений для разделения"))
+		return
+	}
+	splitInputState[chatID] = ticketID
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("✂️ Разделение тикета #%d\n\n", ticketID))
+	messages := ticket.Messages
+	first := 1
+	if len(messages) > 30 {
+		first = len(messages) - 29
+		messages = messages[first-1:]
+		text.WriteString("(последние 30 сообщений)\n")
+	}
+	for i, m := range messages {
+		sender := "👤"
+		if m.IsFromManager {
+			sender = "👨‍💼"
+		}
+		preview := messageDisplayText(m)
+		if utf8.RuneCountInString(preview) > 60 {
+			preview = string([]rune(preview)[:60]) + "…"
+		}
+		text.WriteString(fmt.Sprintf("%d. %s %s %s\n", first+i, sender, m.Time.Format("02.01 15:04"), strings.ReplaceAll(preview, "\n", " ")))
+	}
+	text.WriteString("\nНапишите номера сообщений, которые нужно вынести в новый тикет: диапазон «5-9» или «5» (с 5-го до конца).\n\n" +
+		"Используйте /cancel для отмены.")
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("ticket_view_%d", ticketID)),
+		),
+	)
+	bot.Send(msg)
+}
+
+// parseMessageRange разбирает «5-9» или «5» (до последнего сообщения). Номера — позиции в списке
+// startSplitInput, а не номера сообщений тикета.
+func parseMessageRange(s string, total int) (int, int, error) {
+	from, to, isRange := strings.Cut(strings.ReplaceAll(s, " ", ""), "-")
+	start, err := strconv.Atoi(from)
+	if err != nil {
+		return 0, 0, fmt.Errorf("некорректный номер: %s", from)
+	}
+	end := total
+	if isRange {
+		if end, err = strconv.Atoi(to); err != nil {
+			return 0, 0, fmt.Errorf("некорректный номер: %s", to)
+		}
+	}
+	if start < 1 || end > total || start > end {
+		return 0, 0, fmt.Errorf("номера должны быть от 1 до %d", total)
+	}
+	if start == 1 && end == total {
+		return 0, 0, fmt.Errorf("в исходном тикете должно остаться хотя бы одно сообщение")
+	}
+	return start, end, nil
+}
+
+// handleSplitInput принимает диапазон сообщений и выносит их в новый тикет
+func handleSplitInput(bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
+	chatID := message.Chat.ID
+	ticketID, ok := splitInputState[chatID]
+	if !ok {
+		return false
+	}
+
+	text := strings.TrimSpace(message.Text)
+	if text == "/cancel" {
+		delete(splitInputState, chatID)
+		showTicketDetails(bot, chatID, ticketID)
+		return true
+	}
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		delete(splitInputState, chatID)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return true
+	}
+	start, end, err := parseMessageRange(text, len(ticket.Messages))
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ %s\n\nВведите диапазон еще раз или /cancel", err)))
+		return true
+	}
+	delete(splitInputState, chatID)
+
+	created := splitTicket(bot, ticket, start, end, chatID)
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Сообщения %d–%d вынесены из тикета #%d в новый тикет #%d", start, end, ticket.ID, created.ID)))
+	showTicketDetails(bot, chatID, created.ID)
+	return true
+}
+
+// splitTicket выносит сообщения на позициях start..end в новый открытый тикет того же клиента.
+// Новый тикет проходит обычный путь создания: маршрутизация, карточка менеджерам и тема в группе поддержки.
+func splitTicket(bot *tgbotapi.BotAPI, ticket *Ticket, start, end int, actorID int64) *Ticket {
+	selected := ticket.Messages[start-1 : end]
+	created := &Ticket{
+		ID:              nextTicketID,
+		UserID:          ticket.UserID,
+		Username:        ticket.Username,
+		FirstName:       ticket.FirstName,
+		LastName:        ticket.LastName,
+		Height:          ticket.Height,
+		ChestSize:       ticket.ChestSize,
+		Oversize:        ticket.Oversize,
+		RecommendedSize: ticket.RecommendedSize,
+		Product:         ticket.Product,
+		Category:        ticket.Category,
+		Priority:        ticket.Priority,
+		Status:          "open",
+		CreatedAt:       selected[0].Time,
+		LastMessage:     selected[len(selected)-1].Time,
+	}
+	for _, m := range selected {
+		if !m.IsFromManager {
+			created.Question = m.Text
+			break
+		}
+	}
+	for k, v := range ticket.Measurements {
+		if created.Measurements == nil {
+			created.Measurements = make(map[string]int)
+		}
+		created.Measurements[k] = v
+	}
+	created.Messages = moveMessages(ticket, created, selected)
+	ticket.Messages = append(ticket.Messages[:start-1:start-1], ticket.Messages[end:]...)
+	if ticket.Status == "open" {
+		last := ticket.Messages[len(ticket.Messages)-1].Time
+		if last.Before(ticket.LastMessage) {
+			ticket.LastMessage = last
+		}
+	}
+
+	// Ответственный исходного тикета продолжает вести и выделенную часть; иначе — обычная маршрутизация
+	if ticket.AssigneeID != 0 {
+		setTicketAssignee(created, ticket.AssigneeID)
+	}
+	summary := fmt.Sprintf("%d сообщ. с %s", len(selected), selected[0].Time.Format("02.01 15:04"))
+	logTicketEvent(ticket, "split", actorID, fmt.Sprintf("%s вынес %s в тикет #%d", managerDisplayName(actorID), summary, created.ID))
+	logTicketEvent(created, "split", actorID, fmt.Sprintf("%s создал тикет из тикета #%d (%s)", managerDisplayName(actorID), ticket.ID, summary))
+	registerTicket(created)
+	rebuildTelegramMessageIndex()
+	log.Printf("Из тикета #%d выделен тикет #%d (%s)", ticket.ID, created.ID, summary)
+
+	if ticket.TopicID != 0 {
+		sendTopicMessage(bot, ticket, fmt.Sprintf("✂️ %s вынесено в тикет #%d", summary, created.ID))
+	}
+	sendClientCardToManager(bot, created)
+	if supportGroupEnabled() {
+		postToTicketTopic(bot, created, fmt.Sprintf("✂️ Тикет выделен из #%d: %s. Переписка — в «📋 Диалог»", ticket.ID, summary))
+	}
+
+	sendToClient(bot, created, tgbotapi.NewMessage(created.UserID, fmt.Sprintf(
+		"📂 Часть переписки из обращения #%d вынесена в отдельное обращение #%d — так мы быстрее ответим на каждый вопрос. "+
+			"Переключиться между обращениями можно в «📂 Мои обращения».", ticket.ID, created.ID)), actorID)
+	return created
+}
+
+// mergedLine — отметка для карточки присоединенного тикета
+func mergedLine(ticket *Ticket) string {
+	if ticket.MergedInto == 0 {
+		return ""
+	}
+	return fmt.Sprintf("🔗 Присоединен к тикету #%d\n", ticket.MergedInto)
+}
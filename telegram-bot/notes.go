@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TicketNote — внутренняя заметка менеджера к тикету; клиенту не отправляется
+type TicketNote struct {
+	ID       int       `json:"id"`
+	AuthorID int64     `json:"author_id"`
+	Text     string    `json:"text"`
+	Time     time.Time `json:"time"`
+}
+
+var noteInputState = make(map[int64]int) // chatID менеджера -> ID тикета, к которому пишется заметка
+
+const noteCommand = "/note"
+
+// addTicketNote добавляет заметку к тикету
+func addTicketNote(ticket *Ticket, authorID int64, text string) {
+	ticket.Notes = append(ticket.Notes, TicketNote{
+		ID:       len(ticket.Notes) + 1,
+		AuthorID: authorID,
+		Text:     text,
+		Time:     time.Now(),
+	})
+	saveTickets()
+	log.Printf("Менеджер %d добавил заметку к тикету #%d", authorID, ticket.ID)
+}
+
+// formatTicketNote форматирует заметку для менеджеров
+func formatTicketNote(n TicketNote) string {
+	return fmt.Sprintf("🗒 Заметка %s (%s):\n%s", managerDisplayName(n.AuthorID), n.Time.Format("02.01 15:04"), n.Text)
+}
+
+// ticketNotesText — последние заметки для карточки тикета
+func ticketNotesText(ticket *Ticket, count int) string {
+	if len(ticket.Notes) == 0 {
+		return ""
+	}
+	notes := ticket.Notes
+	if len(notes) > count {
+		notes = notes[len(notes)-count:]
+	}
+	text := fmt.Sprintf("\n🗒 Заметки менеджеров (%d, видны только менеджерам):\n", len(ticket.Notes))
+	for _, n := range notes {
+		text += fmt.Sprintf("• %s, %s: %s\n", n.Time.Format("02.01 15:04"), managerDisplayName(n.AuthorID), n.Text)
+	}
+	return text
+}
+
+// getTicketDialogForManager возвращает диалог тикета вместе с заметками менеджеров (по времени)
+// и статусом доставки ответов клиенту
+func getTicketDialogForManager(ticketID int) string {
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		return "Тикет не найден"
+	}
+	if len(ticket.Messages) == 0 && len(ticket.Notes) == 0 {
+		return "Сообщений пока нет"
+	}
+
+	var result strings.Builder
+	result.WriteString(fmt.Sprintf("📋 Диалог тикета #%d:\n\n", ticketID))
+	notes := ticket.Notes
+	for _, msg := range ticket.Messages {
+		for len(notes) > 0 && notes[0].Time.Before(msg.Time) {
+			result.WriteString(formatTicketNote(notes[0]) + "\n\n")
+			notes = notes[1:]
+		}
+		senderType := "👤 Клиент"
+		if msg.IsFromManager {
+			senderType = "👨‍💼 Менеджер"
+		}
+		status := ""
+		if s := deliveryStatusText(msg); s != "" && msg.RecalledAt.IsZero() {
+			status = "\n" + s
+		}
+		result.WriteString(fmt.Sprintf("%s (%s):\n%s%s\n\n",
+			senderType,
+			msg.Time.Format("02.01.2006 15:04:05"),
+			messageDisplayText(msg), status))
+	}
+	for _, n := range notes {
+		result.WriteString(formatTicketNote(n) + "\n\n")
+	}
+	return result.String()
+}
+
+// startNoteInput ждет текст заметки к тикету
+func startNoteInput(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+	if _, exists := tickets[ticketID]; !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	noteInputState[chatID] = ticketID
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🗒 Заметка к тикету #%d\n\n"+
+		"Напишите текст — его увидят только менеджеры, клиенту заметка не отправляется.\n\n"+
+		"Используйте /cancel для отмены", ticketID))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("ticket_view_%d", ticketID)),
+		),
+	)
+	bot.Send(msg)
+}
+
+// handleNoteInput сохраняет заметку, введенную после кнопки «🗒 Заметка»
+func handleNoteInput(bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
+	chatID := message.Chat.ID
+	ticketID, ok := noteInputState[chatID]
+	if !ok {
+		return false
+	}
+	delete(noteInputState, chatID)
+
+	text := strings.TrimSpace(message.Text)
+	if text == "/cancel" {
+		showTicketDetails(bot, chatID, ticketID)
+		return true
+	}
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return true
+	}
+	if text == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Заметка может быть только текстом"))
+		return true
+	}
+
+	addTicketNote(ticket, chatID, text)
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Заметка добавлена к тикету #%d", ticketID)))
+	showTicketDetails(bot, chatID, ticketID)
+	return true
+}
+
+// handleNoteCommand обрабатывает команду /note:
+//
+//	/note <ID тикета> <текст>
+//	/note <текст> — в активный тикет или в тикет уведомления, на которое дан reply
+//	/note <ID тикета> — запросить текст отдельным сообщением
+func handleNoteCommand(bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
+	if !isNoteCommand(message.Text) {
+		return false
+	}
+	fields := strings.Fields(message.Text)
+	chatID := message.Chat.ID
+	text := strings.TrimSpace(strings.TrimPrefix(message.Text, fields[0]))
+
+	ticketID := 0
+	if len(fields) > 1 {
+		if id, err := strconv.Atoi(fields[1]); err == nil {
+			ticketID = id
+			text = strings.TrimSpace(strings.TrimPrefix(text, fields[1]))
+		}
+	}
+	if ticketID == 0 {
+		if ref, ok := lookupReplyTarget(message); ok {
+			ticketID = ref.TicketID
+		} else if session, ok := managerSessions[chatID]; ok {
+			ticketID = session.TicketID
+		}
+	}
+
+	if ticketID == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "🗒 Использование: /note <ID тикета> <текст>\n\n"+
+			"В активном тикете или в ответ (reply) на уведомление тикета ID можно не указывать."))
+		return true
+	}
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return true
+	}
+	if text == "" {
+		startNoteInput(bot, chatID, ticketID)
+		return true
+	}
+
+	addTicketNote(ticket, chatID, text)
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Заметка добавлена к тикету #%d", ticketID)))
+	return true
+}
+
+// addTopicNote сохраняет заметку, написанную командой /note в теме тикета группы поддержки
+func addTopicNote(bot *tgbotapi.BotAPI, message *tgbotapi.Message, ticket *Ticket) {
+	fields := strings.Fields(message.Text)
+	text := strings.TrimSpace(strings.TrimPrefix(message.Text, fields[0]))
+	if text == "" {
+		sendTopicMessage(bot, ticket, "🗒 Использование: /note <текст>")
+		return
+	}
+	addTicketNote(ticket, message.From.ID, text)
+	sendTopicMessage(bot, ticket, "✅ Заметка сохранена (клиенту не отправлена)")
+}
+
+// isNoteCommand проверяет, начинается ли сообщение с команды /note
+func isNoteCommand(text string) bool {
+	fields := strings.Fields(text)
+	return len(fields) > 0 && strings.EqualFold(strings.SplitN(fields[0], "@", 2)[0], noteCommand)
+}
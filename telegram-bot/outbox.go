@@ -0,0 +1,87 @@
+package main
+
+import (
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// outMessage — запрос к Telegram, подготовленный заранее. Фоновые задачи формируют такие
+// сообщения под stateMu, а отправляют после его снятия, чтобы медленный Telegram не задерживал
+// обработку обновлений (и наоборот).
+type outMessage struct {
+	Msg      tgbotapi.Chattable // сообщение в личный чат
+	TopicID  int                // или текст Text в тему группы поддержки
+	Text     string
+	Endpoint string // или произвольный метод API с параметрами Params (закрытие темы и т.п.)
+	Params   tgbotapi.Params
+	Ticket   *Ticket
+	ToClient bool // сообщение клиенту тикета: при 403 тикет помечается недоставляемым
+	Link     bool // связать отправленное сообщение с тикетом для ответов через reply
+}
+
+// send выполняет запрос, не трогая общее состояние
+func (o outMessage) send(bot *tgbotapi.BotAPI) (tgbotapi.Message, error) {
+	switch {
+	case o.Msg != nil:
+		return bot.Send(o.Msg)
+	case o.TopicID != 0:
+		return sendToTopic(bot, o.TopicID, o.Text)
+	default:
+		_, err := bot.MakeRequest(o.Endpoint, o.Params)
+		return tgbotapi.Message{}, err
+	}
+}
+
+// applyResult сохраняет результат отправки в тикете (вызывается под stateMu) и возвращает
+// сообщения, которые нужно отправить следом
+func (o outMessage) applyResult(sent tgbotapi.Message, err error) []outMessage {
+	if err != nil {
+		if o.Ticket != nil {
+			log.Printf("Ошибка отправки по тикету #%d: %v", o.Ticket.ID, err)
+		} else {
+			log.Printf("Ошибка отправки: %v", err)
+		}
+		if o.ToClient && isClientUnreachable(err) {
+			return setTicketUndeliverable(o.Ticket, err, 0)
+		}
+		return nil
+	}
+	if o.Link && o.Ticket != nil {
+		linkTicketNotification(o.Ticket, sent)
+	}
+	return nil
+}
+
+// sendOutbox отправляет сообщения сразу — для кода, который уже держит stateMu (обработка обновлений)
+func sendOutbox(bot *tgbotapi.BotAPI, out []outMessage) {
+	for len(out) > 0 {
+		var next []outMessage
+		for _, o := range out {
+			sent, err := o.send(bot)
+			next = append(next, o.applyResult(sent, err)...)
+		}
+		out = next
+	}
+}
+
+// deliverOutbox отправляет сообщения фоновой задачи без stateMu и затем под stateMu
+// сохраняет результаты. Вызывается, когда stateMu не захвачен.
+func deliverOutbox(bot *tgbotapi.BotAPI, out []outMessage) {
+	for len(out) > 0 {
+		sent := make([]tgbotapi.Message, len(out))
+		errs := make([]error, len(out))
+		for i, o := range out {
+			sent[i], errs[i] = o.send(bot)
+		}
+
+		stateMu.Lock()
+		var next []outMessage
+		for i, o := range out {
+			next = append(next, o.applyResult(sent[i], errs[i])...)
+		}
+		saveTickets()
+		stateMu.Unlock()
+		out = next
+	}
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// TicketRating — оценка клиентом работы по закрытому тикету
+type TicketRating struct {
+	Score     int       `json:"score"` // от 1 до 5
+	Comment   string    `json:"comment,omitempty"`
+	ManagerID int64     `json:"manager_id,omitempty"` // менеджер, которому засчитывается оценка
+	Time      time.Time `json:"time"`
+}
+
+const maxRatingScore = 5
+
+var ratingCommentState = make(map[int64]int) // chatID клиента -> ID тикета, к оценке которого ждем комментарий
+
+// ratingStars — оценка звездочками
+func ratingStars(score int) string {
+	return strings.Repeat("⭐", score)
+}
+
+// ratingKeyboard — кнопки оценки тикета от 1 до 5
+func ratingKeyboard(ticketID int) tgbotapi.InlineKeyboardMarkup {
+	var row []tgbotapi.InlineKeyboardButton
+	for score := 1; score <= maxRatingScore; score++ {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d⭐", score), fmt.Sprintf("rate_%d_%d", ticketID, score)))
+	}
+	return tgbotapi.NewInlineKeyboardMarkup(row)
+}
+
+// ratedManagerID определяет, кому засчитать оценку: ответственному,
+// иначе менеджеру, ответившему последним, иначе закрывшему тикет
+func ratedManagerID(ticket *Ticket) int64 {
+	if ticket.AssigneeID != 0 {
+		return ticket.AssigneeID
+	}
+	for i := len(ticket.Messages) - 1; i >= 0; i-- {
+		if m := ticket.Messages[i]; m.IsFromManager && m.SenderID != 0 {
+			return m.SenderID
+		}
+	}
+	for i := len(ticket.Events) - 1; i >= 0; i-- {
+		if e := ticket.Events[i]; e.Kind == "closed" && e.ActorID != 0 {
+			return e.ActorID
+		}
+	}
+	return 0
+}
+
+// ticketRatingLine — строка с оценкой для карточки тикета
+func ticketRatingLine(ticket *Ticket) string {
+	if ticket.Rating == nil {
+		return ""
+	}
+	line := fmt.Sprintf("⭐ Оценка клиента: %d/%d", ticket.Rating.Score, maxRatingScore)
+	if ticket.Rating.Comment != "" {
+		line += fmt.Sprintf(" — «%s»", ticket.Rating.Comment)
+	}
+	return line + "\n"
+}
+
+// handleRatingCallback обрабатывает кнопки rate_<ID>_<оценка> и rate_skip_<ID>
+func handleRatingCallback(bot *tgbotapi.BotAPI, callback *tgbotapi.CallbackQuery) {
+	chatID := callback.Message.Chat.ID
+	data := callback.Data
+
+	if strings.HasPrefix(data, "rate_skip_") {
+		if ticketID, err := strconv.Atoi(strings.TrimPrefix(data, "rate_skip_")); err == nil && ratingCommentState[chatID] == ticketID {
+			delete(ratingCommentState, chatID)
+		}
+		bot.Send(tgbotapi.NewMessage(chatID, "🙏 Спасибо за оценку!"))
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(data, "rate_"), "_")
+	if len(parts) != 2 {
+		return
+	}
+	ticketID, err1 := strconv.Atoi(parts[0])
+	score, err2 := strconv.Atoi(parts[1])
+	if err1 != nil || err2 != nil || score < 1 || score > maxRatingScore {
+		return
+	}
+	ticket, exists := tickets[ticketID]
+	if !exists || ticket.UserID != callback.From.ID {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	if ticket.Status != "closed" {
+		bot.Send(tgbotapi.NewMessage(chatID, "ℹ️ Диалог снова открыт — оценить его можно после закрытия"))
+		return
+	}
+	if ticket.Rating != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("ℹ️ Вы уже оценили тикет #%d: %s", ticketID, ratingStars(ticket.Rating.Score))))
+		return
+	}
+
+	ticket.Rating = &TicketRating{Score: score, ManagerID: ratedManagerID(ticket), Time: time.Now()}
+	logTicketEvent(ticket, "rated", ticket.UserID, fmt.Sprintf("Клиент оценил работу: %d/%d", score, maxRatingScore))
+	saveTickets()
+	log.Printf("Клиент %d оценил тикет #%d на %d", chatID, ticketID, score)
+
+	// Убираем кнопки из сообщения о закрытии, чтобы оценку нельзя было поставить повторно
+	edit := tgbotapi.NewEditMessageText(chatID, callback.Message.MessageID,
+		callback.Message.Text+"\n\nВаша оценка: "+ratingStars(score))
+	bot.Send(edit)
+
+	notifyRatedManager(bot, ticket)
+
+	ratingCommentState[chatID] = ticketID
+	msg := tgbotapi.NewMessage(chatID, "🙏 Спасибо за оценку!\n\nЕсли хотите, напишите комментарий одним сообщением — что понравилось или что можно улучшить.")
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("⏭ Пропустить", fmt.Sprintf("rate_skip_%d", ticketID)),
+		),
+	)
+	bot.Send(msg)
+}
+
+// handleRatingComment сохраняет комментарий клиента к оценке.
+// Пока клиент ведет диалог в тикете, сообщения уходят менеджеру, а не в комментарий.
+func handleRatingComment(bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
+	chatID := message.Chat.ID
+	ticketID, ok := ratingCommentState[chatID]
+	if !ok || questionStates[chatID] {
+		return false
+	}
+	delete(ratingCommentState, chatID)
+
+	text := strings.TrimSpace(message.Text)
+	if strings.HasPrefix(text, "/") {
+		return false
+	}
+	ticket, exists := tickets[ticketID]
+	if !exists || ticket.Rating == nil || text == "" {
+		return false
+	}
+
+	ticket.Rating.Comment = text
+	saveTickets()
+	bot.Send(tgbotapi.NewMessage(chatID, "🙏 Спасибо! Мы передали ваш комментарий команде."))
+	notifyRatedManager(bot, ticket)
+	return true
+}
+
+// notifyRatedManager сообщает менеджеру об оценке (и комментарии) по его тикету
+func notifyRatedManager(bot *tgbotapi.BotAPI, ticket *Ticket) {
+	r := ticket.Rating
+	if r == nil || r.ManagerID == 0 || !isManagerID(r.ManagerID) {
+		return
+	}
+	text := fmt.Sprintf("⭐ Клиент оценил тикет #%d: %s (%d/%d)", ticket.ID, ratingStars(r.Score), r.Score, maxRatingScore)
+	if r.Comment != "" {
+		text += fmt.Sprintf("\n\n💬 Комментарий: %s", r.Comment)
+	}
+	msg := tgbotapi.NewMessage(r.ManagerID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("👁 Тикет", fmt.Sprintf("ticket_view_%d", ticket.ID)),
+		),
+	)
+	bot.Send(msg)
+}
+
+// managerRating — сводка оценок одного менеджера
+type managerRating struct {
+	ManagerID int64
+	Count     int
+	Sum       int
+	Comments  int
+	Scores    [maxRatingScore + 1]int // число оценок по баллам, индекс — балл
+}
+
+// Average — средняя оценка
+func (r managerRating) Average() float64 {
+	if r.Count == 0 {
+		return 0
+	}
+	return float64(r.Sum) / float64(r.Count)
+}
+
+// collectRatings собирает оценки по менеджерам (по убыванию числа оценок) и общую сводку
+func collectRatings() ([]managerRating, managerRating) {
+	var total managerRating
+	byManager := make(map[int64]*managerRating)
+	for _, t := range tickets {
+		r := t.Rating
+		if r == nil || r.Score < 1 || r.Score > maxRatingScore {
+			continue
+		}
+		m, ok := byManager[r.ManagerID]
+		if !ok {
+			m = &managerRating{ManagerID: r.ManagerID}
+			byManager[r.ManagerID] = m
+		}
+		for _, agg := range []*managerRating{m, &total} {
+			agg.Count++
+			agg.Sum += r.Score
+			agg.Scores[r.Score]++
+			if r.Comment != "" {
+				agg.Comments++
+			}
+		}
+	}
+
+	list := make([]managerRating, 0, len(byManager))
+	for _, m := range byManager {
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].ManagerID < list[j].ManagerID
+	})
+	return list, total
+}
+
+// ratingStatsText — блок оценок клиентов для экрана статистики
+func ratingStatsText() string {
+	list, total := collectRatings()
+	if total.Count == 0 {
+		return "⭐ Оценки клиентов: пока нет"
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("⭐ Оценки клиентов: %.1f из %d (оценок: %d, с комментарием: %d)\n",
+		total.Average(), maxRatingScore, total.Count, total.Comments))
+	for score := maxRatingScore; score >= 1; score-- {
+		text.WriteString(fmt.Sprintf("%d⭐ — %d\n", score, total.Scores[score]))
+	}
+	text.WriteString("\n👨‍💼 По менеджерам:\n")
+	for _, m := range list {
+		name := "не определен"
+		if m.ManagerID != 0 {
+			name = managerDisplayName(m.ManagerID)
+		}
+		text.WriteString(fmt.Sprintf("• %s: %.1f (%d)\n", name, m.Average(), m.Count))
+	}
+	return strings.TrimRight(text.String(), "\n")
+}
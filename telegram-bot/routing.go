@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Стратегии автоматического распределения новых тикетов (TICKET_ROUTING)
+const (
+	routingManual     = "manual"      // без назначения, менеджеры берут тикеты сами
+	routingRoundRobin = "round_robin" // по очереди
+	routingLeastOpen  = "least_open"  // менеджеру с наименьшим числом открытых тикетов
+	routingSticky     = "sticky"      // тому же менеджеру, что вел прошлый тикет клиента
+)
+
+// ticketRoutingStrategy возвращает стратегию распределения из переменной окружения
+func ticketRoutingStrategy() string {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("TICKET_ROUTING")))
+	switch v {
+	case "", routingManual:
+		return routingManual
+	case routingRoundRobin, routingLeastOpen, routingSticky:
+		return v
+	default:
+		log.Printf("Неизвестная стратегия TICKET_ROUTING: %s, используется manual", v)
+		return routingManual
+	}
+}
+
+// routingStrategyText возвращает название стратегии для отображения
+func routingStrategyText(strategy string) string {
+	switch strategy {
+	case routingRoundRobin:
+		return "по очереди"
+	case routingLeastOpen:
+		return "наименее загруженному"
+	case routingSticky:
+		return "закрепление за менеджером клиента"
+	default:
+		return "вручную"
+	}
+}
+
+// routeTicket назначает новый тикет менеджеру на смене по выбранной стратегии
+// (сохранение — на вызывающей стороне). Если на смене никого нет, тикет остается в общей очереди.
+func routeTicket(ticket *Ticket) {
+	strategy := ticketRoutingStrategy()
+	if strategy == routingManual || ticket.AssigneeID != 0 {
+		return
+	}
+
+	candidates := onShiftManagerIDs()
+	if len(candidates) == 0 {
+		log.Printf("Тикет #%d: нет менеджеров на смене, назначение пропущено", ticket.ID)
+		return
+	}
+
+	var managerID int64
+	switch strategy {
+	case routingRoundRobin:
+		managerID = routeRoundRobin(ticket, candidates)
+	case routingLeastOpen:
+		managerID = routeLeastOpen(candidates)
+	case routingSticky:
+		managerID = routeSticky(ticket, candidates)
+	}
+	if managerID == 0 {
+		return
+	}
+
+	setTicketAssignee(ticket, managerID)
+	logTicketEvent(ticket, "routed", 0, fmt.Sprintf("Автоматически назначен %s (%s)",
+		managerDisplayName(managerID), routingStrategyText(strategy)))
+	log.Printf("Тикет #%d автоматически назначен менеджеру %d (%s)", ticket.ID, managerID, strategy)
+}
+
+// routeRoundRobin выбирает следующего менеджера после того, кому назначен последний тикет
+func routeRoundRobin(ticket *Ticket, candidates []int64) int64 {
+	var lastID int
+	var lastManager int64
+	for id, t := range tickets {
+		if id != ticket.ID && t.AssigneeID != 0 && id > lastID {
+			lastID = id
+			lastManager = t.AssigneeID
+		}
+	}
+	for i, mid := range candidates {
+		if mid == lastManager {
+			return candidates[(i+1)%len(candidates)]
+		}
+	}
+	// Последний назначенный не на смене — ищем первого с большим ID
+	for _, mid := range candidates {
+		if mid > lastManager {
+			return mid
+		}
+	}
+	return candidates[0]
+}
+
+// routeLeastOpen выбирает менеджера с наименьшим числом открытых тикетов
+func routeLeastOpen(candidates []int64) int64 {
+	open := make(map[int64]int)
+	for _, t := range tickets {
+		if t.Status == "open" && t.AssigneeID != 0 {
+			open[t.AssigneeID]++
+		}
+	}
+	best := candidates[0]
+	for _, mid := range candidates[1:] {
+		if open[mid] < open[best] {
+			best = mid
+		}
+	}
+	return best
+}
+
+// routeSticky выбирает менеджера предыдущего тикета клиента, иначе — наименее загруженного
+func routeSticky(ticket *Ticket, candidates []int64) int64 {
+	var prevID int
+	var prevManager int64
+	for id, t := range tickets {
+		if id != ticket.ID && t.UserID == ticket.UserID && t.AssigneeID != 0 && id > prevID {
+			prevID = id
+			prevManager = t.AssigneeID
+		}
+	}
+	for _, mid := range candidates {
+		if mid == prevManager {
+			return mid
+		}
+	}
+	return routeLeastOpen(candidates)
+}
+
+// onShiftManagerIDs возвращает отсортированный список менеджеров на смене
+func onShiftManagerIDs() []int64 {
+	var ids []int64
+	for _, id := range getManagerIDs() {
+		if isOnShift(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ticketSearch — разобранный поисковый запрос менеджера. Все условия объединяются по «И».
+type ticketSearch struct {
+	Query    string // исходный текст запроса (для заголовка списка)
+	Username string // @username — подстрока username клиента
+	UserID   int64  // id:123 — ID клиента
+	Assignee string // assignee:имя — подстрока имени ответственного
+	From     time.Time
+	To       time.Time // включительно (конец дня)
+	Words    []string  // слова: каждое должно встретиться в имени, username, товаре, вопросе или сообщениях
+	Phrases  []string  // "фраза в кавычках" — ищется целиком
+}
+
+// searchHelpText — подсказка по синтаксису поиска
+const searchHelpText = "🔍 Поиск тикетов\n\n" +
+	"Напишите номер тикета или слова для поиска по имени, username и тексту сообщений. Можно добавить условия:\n" +
+	"• @username — клиент по username\n" +
+	"• id:123456 — клиент по ID\n" +
+	"• #тег — тег тикета\n" +
+	"• status:open / status:closed — статус\n" +
+	"• assignee:имя — ответственный менеджер\n" +
+	"• from:01.05.2024 to:31.05.2024 — тикеты, активные в этот период\n" +
+	"• days:7 — активность за последние N дней\n" +
+	"• \"черная худи\" — точная фраза\n\n" +
+	"Пример: худи черн days:7 status:open\n\n" +
+	"Используйте /cancel для отмены"
+
+// parseSearchDate разбирает дату в формате 02.01.2006 или 2006-01-02
+func parseSearchDate(s string) (time.Time, bool) {
+	for _, layout := range []string{"02.01.2006", "2006-01-02", "02.01.06"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// splitSearchQuery делит запрос на токены, сохраняя фразы в кавычках целиком
+func splitSearchQuery(query string) []string {
+	var tokens []string
+	var current strings.Builder
+	inQuotes := false
+	flush := func() {
+		if current.Len() > 0 {
+			tokens = append(tokens, current.String())
+			current.Reset()
+		}
+	}
+	for _, r := range query {
+		switch {
+		case r == '"' || r == '«' || r == '»':
+			if inQuotes {
+				// Фразу помечаем кавычкой в начале, чтобы отличить от обычного слова
+				tokens = append(tokens, "\""+current.String())
+				current.Reset()
+			} else {
+				flush()
+			}
+			inQuotes = !inQuotes
+		case unicode.IsSpace(r) && !inQuotes:
+			flush()
+		default:
+			current.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// parseTicketSearch разбирает запрос в условия поиска и фильтры списка (статус, тег)
+func parseTicketSearch(query string, filter *ticketListFilter) (*ticketSearch, error) {
+	s := &ticketSearch{Query: strings.TrimSpace(query)}
+	for _, token := range splitSearchQuery(query) {
+		if strings.HasPrefix(token, "\"") {
+			if phrase := strings.ToLower(strings.TrimSpace(token[1:])); phrase != "" {
+				s.Phrases = append(s.Phrases, phrase)
+			}
+			continue
+		}
+
+		key, value, hasKey := strings.Cut(token, ":")
+		key = strings.ToLower(key)
+		switch {
+		case strings.HasPrefix(token, "@") && len(token) > 1:
+			s.Username = strings.ToLower(token[1:])
+		case strings.HasPrefix(token, "#") && len(token) > 1:
+			filter.Tag = normalizeTag(token[1:])
+		case hasKey && (key == "id" || key == "user"):
+			id, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("некорректный ID клиента: %s", value)
+			}
+			s.UserID = id
+		case hasKey && (key == "status" || key == "статус"):
+			switch strings.ToLower(value) {
+			case "open", "открыт", "открытые":
+				filter.Status = "open"
+			case "closed", "закрыт", "закрытые":
+				filter.Status = "closed"
+			default:
+				return nil, fmt.Errorf("неизвестный статус: %s (open или closed)", value)
+			}
+		case hasKey && (key == "assignee" || key == "менеджер"):
+			s.Assignee = strings.ToLower(strings.TrimPrefix(value, "@"))
+		case hasKey && (key == "from" || key == "с"):
+			t, ok := parseSearchDate(value)
+			if !ok {
+				return nil, fmt.Errorf("некорректная дата: %s (ДД.ММ.ГГГГ)", value)
+			}
+			s.From = t
+		case hasKey && (key == "to" || key == "по"):
+			t, ok := parseSearchDate(value)
+			if !ok {
+				return nil, fmt.Errorf("некорректная дата: %s (ДД.ММ.ГГГГ)", value)
+			}
+			s.To = t.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		case hasKey && (key == "days" || key == "дней"):
+			days, err := strconv.Atoi(value)
+			if err != nil || days <= 0 {
+				return nil, fmt.Errorf("некорректное число дней: %s", value)
+			}
+			s.From = time.Now().AddDate(0, 0, -days)
+		default:
+			s.Words = append(s.Words, strings.ToLower(token))
+		}
+	}
+	return s, nil
+}
+
+// ticketSearchText — текст тикета, по которому ищутся слова и фразы
+func ticketSearchText(ticket *Ticket) string {
+	var b strings.Builder
+	for _, part := range []string{ticket.FirstName, ticket.LastName, ticket.Username, ticket.Product, ticket.Question, ticket.RecommendedSize} {
+		b.WriteString(part)
+		b.WriteString("\n")
+	}
+	for _, m := range ticket.Messages {
+		b.WriteString(m.Text)
+		b.WriteString("\n")
+		for _, a := range m.Attachments {
+			b.WriteString(a.FileName)
+			b.WriteString("\n")
+		}
+	}
+	return strings.ToLower(b.String())
+}
+
+// matches проверяет, подходит ли тикет под условия поиска
+func (s *ticketSearch) matches(ticket *Ticket) bool {
+	if s.UserID != 0 && ticket.UserID != s.UserID {
+		return false
+	}
+	if s.Username != "" && !strings.Contains(strings.ToLower(ticket.Username), s.Username) {
+		return false
+	}
+	if s.Assignee != "" && (ticket.AssigneeID == 0 || !strings.Contains(strings.ToLower(assigneeText(ticket)), s.Assignee)) {
+		return false
+	}
+	if !s.From.IsZero() && ticket.LastMessage.Before(s.From) {
+		return false
+	}
+	if !s.To.IsZero() && ticket.CreatedAt.After(s.To) {
+		return false
+	}
+	if len(s.Words) == 0 && len(s.Phrases) == 0 {
+		return true
+	}
+
+	text := ticketSearchText(ticket)
+	for _, w := range s.Words {
+		// Число без префикса — это может быть и ID клиента
+		if id, err := strconv.ParseInt(w, 10, 64); err == nil && id == ticket.UserID {
+			continue
+		}
+		if !strings.Contains(text, w) {
+			return false
+		}
+	}
+	for _, p := range s.Phrases {
+		if !strings.Contains(text, p) {
+			return false
+		}
+	}
+	return true
+}
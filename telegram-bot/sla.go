@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const slaCheckInterval = time.Minute
+
+// Рабочие часы менеджеров
+const (
+	businessHourStart = 9
+	businessHourEnd   = 20
+)
+
+// SLABreach — нарушение SLA: напоминание ответственному или эскалация администраторам
+type SLABreach struct {
+	Kind       string    `json:"kind"`  // "remind", "escalate"
+	Since      time.Time `json:"since"` // начало ожидания клиента
+	At         time.Time `json:"at"`
+	AssigneeID int64     `json:"assignee_id,omitempty"`
+}
+
+// slaRemindAfter — рабочее время без ответа до напоминания ответственному (SLA_REMIND_MINUTES, 0 — отключено)
+func slaRemindAfter() time.Duration {
+	return time.Duration(envInt("SLA_REMIND_MINUTES", 30)) * time.Minute
+}
+
+// slaEscalateAfter — рабочее время без ответа до эскалации администраторам (SLA_ESCALATE_MINUTES, 0 — отключено)
+func slaEscalateAfter() time.Duration {
+	return time.Duration(envInt("SLA_ESCALATE_MINUTES", 120)) * time.Minute
+}
+
+// isBusinessTime проверяет, попадает ли момент в рабочие часы
+func isBusinessTime(t time.Time) bool {
+	h := t.Hour()
+	return h >= businessHourStart && h < businessHourEnd
+}
+
+// businessDuration считает, сколько рабочего времени прошло между from и to
+func businessDuration(from, to time.Time) time.Duration {
+	var total time.Duration
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location()); day.Before(to); day = day.AddDate(0, 0, 1) {
+		start := day.Add(businessHourStart * time.Hour)
+		end := day.Add(businessHourEnd * time.Hour)
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			total += end.Sub(start)
+		}
+	}
+	return total
+}
+
+// formatWait форматирует длительность ожидания: "2 ч 15 мин"
+func formatWait(d time.Duration) string {
+	minutes := int(d.Minutes())
+	if minutes < 60 {
+		return fmt.Sprintf("%d мин", minutes)
+	}
+	return fmt.Sprintf("%d ч %d мин", minutes/60, minutes%60)
+}
+
+// hasSLABreach проверяет, зафиксировано ли уже нарушение данного вида для текущего ожидания
+func hasSLABreach(ticket *Ticket, kind string, since time.Time) bool {
+	for _, b := range ticket.SLABreaches {
+		if b.Kind == kind && b.Since.Equal(since) {
+			return true
+		}
+	}
+	return false
+}
+
+// startSLAScheduler запускает фоновую проверку открытых тикетов на нарушение SLA
+func startSLAScheduler(bot *tgbotapi.BotAPI) {
+	go func() {
+		log.Printf("⏰ Запущена проверка SLA каждые %v (напоминание: %v, эскалация: %v)",
+			slaCheckInterval, slaRemindAfter(), slaEscalateAfter())
+
+		ticker := time.NewTicker(slaCheckInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if !isBusinessTime(time.Now()) {
+				continue
+			}
+			stateMu.Lock()
+			out := checkSLA()
+			stateMu.Unlock()
+			deliverOutbox(bot, out)
+		}
+	}()
+}
+
+// checkSLA фиксирует нарушения SLA и возвращает напоминания и эскалации по тикетам,
+// где клиент ждет ответа
+func checkSLA() []outMessage {
+	remindAfter, escalateAfter := slaRemindAfter(), slaEscalateAfter()
+	now := time.Now()
+	var out []outMessage
+
+	for _, ticket := range tickets {
+		if ticket.Status != "open" {
+			continue
+		}
+		since := unansweredSince(ticket)
+		if since.IsZero() {
+			continue
+		}
+		waited := businessDuration(since, now)
+
+		if escalateAfter > 0 && waited >= escalateAfter && !hasSLABreach(ticket, "escalate", since) {
+			out = append(out, escalateTicket(ticket, since, waited)...)
+		} else if remindAfter > 0 && waited >= remindAfter && !hasSLABreach(ticket, "remind", since) && !hasSLABreach(ticket, "escalate", since) {
+			out = append(out, remindAssignee(ticket, since, waited)...)
+		}
+	}
+
+	if len(out) > 0 {
+		saveTickets()
+	}
+	return out
+}
+
+// remindAssignee готовит напоминание ответственному (или всем, если тикет не взят) об ожидающем клиенте
+func remindAssignee(ticket *Ticket, since time.Time, waited time.Duration) []outMessage {
+	ticket.SLABreaches = append(ticket.SLABreaches, SLABreach{
+		Kind:       "remind",
+		Since:      since,
+		At:         time.Now(),
+		AssigneeID: ticket.AssigneeID,
+	})
+	logTicketEvent(ticket, "sla_remind", 0, fmt.Sprintf("Напоминание: клиент ждет ответа %s", formatWait(waited)))
+
+	text := fmt.Sprintf("⏰ Клиент ждет ответа %s (рабочее время) по тикету #%d", formatWait(waited), ticket.ID)
+	var out []outMessage
+	if supportGroupEnabled() && ticket.TopicID != 0 {
+		out = append(out, outMessage{TopicID: ticket.TopicID, Text: text, Ticket: ticket, Link: true})
+	}
+	ids, _ := ticketNotifyRecipients(ticket)
+	for _, mid := range ids {
+		msg := tgbotapi.NewMessage(mid, text)
+		msg.ReplyMarkup = ticketCardKeyboard(ticket)
+		out = append(out, outMessage{Msg: msg, Ticket: ticket, Link: true})
+	}
+
+	log.Printf("SLA: напоминание по тикету #%d, ожидание %v", ticket.ID, waited)
+	return out
+}
+
+// escalateTicket готовит сообщения администраторам о тикете, оставшемся без ответа
+func escalateTicket(ticket *Ticket, since time.Time, waited time.Duration) []outMessage {
+	ticket.SLABreaches = append(ticket.SLABreaches, SLABreach{
+		Kind:       "escalate",
+		Since:      since,
+		At:         time.Now(),
+		AssigneeID: ticket.AssigneeID,
+	})
+	logTicketEvent(ticket, "sla_escalate", 0, fmt.Sprintf("Эскалация администраторам: без ответа %s", formatWait(waited)))
+
+	text := fmt.Sprintf("🚨 Эскалация: тикет #%d без ответа %s (рабочее время)\n🙋 Ответственный: %s",
+		ticket.ID, formatWait(waited), assigneeText(ticket))
+	ids := getAdminIDs()
+	if len(ids) == 0 {
+		log.Printf("SLA: администраторы не заданы, эскалация по тикету #%d не отправлена", ticket.ID)
+		return nil
+	}
+	var out []outMessage
+	for _, aid := range ids {
+		msg := tgbotapi.NewMessage(aid, text)
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("👁 Открыть", fmt.Sprintf("ticket_view_%d", ticket.ID)),
+			),
+		)
+		out = append(out, outMessage{Msg: msg, Ticket: ticket})
+	}
+
+	log.Printf("SLA: эскалация по тикету #%d, ожидание %v", ticket.ID, waited)
+	return out
+}
+
+// slaStats считает нарушения SLA по видам
+func slaStats() (reminders, escalations, ticketsWithBreaches int) {
+	for _, t := range tickets {
+		if len(t.SLABreaches) > 0 {
+			ticketsWithBreaches++
+		}
+		for _, b := range t.SLABreaches {
+			switch b.Kind {
+			case "remind":
+				reminders++
+			case "escalate":
+				escalations++
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Режим группы поддержки: супергруппа менеджеров с включенными темами (форумом),
+// где у каждого тикета своя тема. Включается переменной SUPPORT_GROUP_ID.
+var supportGroupChatID int64
+
+// topicTickets: ID темы в группе поддержки -> ID тикета; восстанавливается при загрузке
+var topicTickets = make(map[int]int)
+
+const maxTopicNameLength = 128
+
+// initSupportGroup читает ID группы поддержки из переменной окружения
+func initSupportGroup() {
+	v := strings.TrimSpace(os.Getenv("SUPPORT_GROUP_ID"))
+	if v == "" || v == "0" {
+		return
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		log.Printf("Некорректный SUPPORT_GROUP_ID: %v", err)
+		return
+	}
+	supportGroupChatID = id
+	log.Printf("Включен режим группы поддержки: %d", id)
+}
+
+func supportGroupEnabled() bool {
+	return supportGroupChatID != 0
+}
+
+func isSupportGroupChat(chatID int64) bool {
+	return supportGroupEnabled() && chatID == supportGroupChatID
+}
+
+// rebuildTopicIndex восстанавливает связь тем группы с тикетами
+func rebuildTopicIndex() {
+	topicTickets = make(map[int]int)
+	for id, t := range tickets {
+		if t.TopicID != 0 {
+			topicTickets[t.TopicID] = id
+		}
+	}
+}
+
+// ticketTopicName формирует название темы тикета
+func ticketTopicName(ticket *Ticket) string {
+	name := strings.TrimSpace(ticket.FirstName + " " + ticket.LastName)
+	if ticket.Username != "" {
+		name = strings.TrimSpace(name + " @" + ticket.Username)
+	}
+	if name == "" {
+		name = fmt.Sprintf("ID %d", ticket.UserID)
+	}
+	title := fmt.Sprintf("#%d %s", ticket.ID, name)
+	if utf8.RuneCountInString(title) > maxTopicNameLength {
+		title = string([]rune(title)[:maxTopicNameLength])
+	}
+	return title
+}
+
+// ensureTicketTopic создает тему для тикета в группе поддержки, если ее еще нет
+func ensureTicketTopic(bot *tgbotapi.BotAPI, ticket *Ticket) bool {
+	if !supportGroupEnabled() {
+		return false
+	}
+	if ticket.TopicID != 0 {
+		return true
+	}
+
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", supportGroupChatID)
+	params["name"] = ticketTopicName(ticket)
+	resp, err := bot.MakeRequest("createForumTopic", params)
+	if err != nil {
+		log.Printf("Ошибка создания темы для тикета #%d: %v", ticket.ID, err)
+		return false
+	}
+	var topic struct {
+		MessageThreadID int `json:"message_thread_id"`
+	}
+	if err := json.Unmarshal(resp.Result, &topic); err != nil || topic.MessageThreadID == 0 {
+		log.Printf("Некорректный ответ createForumTopic для тикета #%d: %s", ticket.ID, string(resp.Result))
+		return false
+	}
+
+	ticket.TopicID = topic.MessageThreadID
+	topicTickets[ticket.TopicID] = ticket.ID
+	saveTickets()
+	log.Printf("Создана тема %d для тикета #%d", ticket.TopicID, ticket.ID)
+	return true
+}
+
+// sendTopicMessage отправляет текст в тему тикета
+func sendTopicMessage(bot *tgbotapi.BotAPI, ticket *Ticket, text string) (tgbotapi.Message, error) {
+	return sendToTopic(bot, ticket.TopicID, text)
+}
+
+// sendToTopic отправляет текст в тему группы поддержки по ее ID
+func sendToTopic(bot *tgbotapi.BotAPI, topicID int, text string) (tgbotapi.Message, error) {
+	var sent tgbotapi.Message
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", supportGroupChatID)
+	params.AddNonZero("message_thread_id", topicID)
+	params["text"] = text
+	resp, err := bot.MakeRequest("sendMessage", params)
+	if err != nil {
+		return sent, err
+	}
+	err = json.Unmarshal(resp.Result, &sent)
+	return sent, err
+}
+
+// copyToTopic копирует сообщение (с вложениями) из чата в тему тикета
+func copyToTopic(bot *tgbotapi.BotAPI, ticket *Ticket, fromChatID int64, messageID int) (tgbotapi.Message, error) {
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", supportGroupChatID)
+	params.AddNonZero("message_thread_id", ticket.TopicID)
+	params.AddNonZero64("from_chat_id", fromChatID)
+	params.AddNonZero("message_id", messageID)
+	resp, err := bot.MakeRequest("copyMessage", params)
+	if err != nil {
+		return tgbotapi.Message{}, err
+	}
+	var copied tgbotapi.MessageID
+	if err := json.Unmarshal(resp.Result, &copied); err != nil {
+		return tgbotapi.Message{}, err
+	}
+	return tgbotapi.Message{MessageID: copied.MessageID, Chat: &tgbotapi.Chat{ID: supportGroupChatID}}, nil
+}
+
+// postToTicketTopic отправляет служебное сообщение в тему тикета (создавая тему при необходимости)
+func postToTicketTopic(bot *tgbotapi.BotAPI, ticket *Ticket, text string) {
+	if !ensureTicketTopic(bot, ticket) {
+		return
+	}
+	if sent, err := sendTopicMessage(bot, ticket, text); err != nil {
+		log.Printf("Ошибка отправки в тему тикета #%d: %v", ticket.ID, err)
+	} else {
+		linkTicketNotification(ticket, sent)
+	}
+}
+
+// mirrorClientMessageToTopic копирует сообщение клиента в тему тикета. Сообщение, сформированное
+// ботом (без исходного сообщения Telegram), публикуется текстом.
+func mirrorClientMessageToTopic(bot *tgbotapi.BotAPI, ticket *Ticket, messageID int) {
+	msg := findTicketMessage(ticket, messageID)
+	if msg == nil || !ensureTicketTopic(bot, ticket) {
+		return
+	}
+	var sent tgbotapi.Message
+	var err error
+	if msg.TgMessageID != 0 {
+		sent, err = copyToTopic(bot, ticket, ticket.UserID, msg.TgMessageID)
+	} else {
+		sent, err = sendTopicMessage(bot, ticket, "👤 Клиент:\n\n"+messageDisplayText(*msg))
+	}
+	if err != nil {
+		log.Printf("Ошибка копирования сообщения в тему тикета #%d: %v", ticket.ID, err)
+		return
+	}
+	linkMessageMirror(ticket, messageID, sent)
+}
+
+// mirrorManagerReplyToTopic дублирует в тему ответ, который менеджер отправил из личного чата с ботом
+func mirrorManagerReplyToTopic(bot *tgbotapi.BotAPI, ticket *Ticket, messageID int, managerID int64) {
+	msg := findTicketMessage(ticket, messageID)
+	if msg == nil || !ensureTicketTopic(bot, ticket) {
+		return
+	}
+	text := fmt.Sprintf("👨‍💼 %s ответил клиенту:\n\n%s", managerDisplayName(managerID), messageDisplayText(*msg))
+	if sent, err := sendTopicMessage(bot, ticket, text); err == nil {
+		linkMessageMirror(ticket, messageID, sent)
+	}
+}
+
+// setTicketTopicClosed закрывает или открывает тему тикета вместе с тикетом
+func setTicketTopicClosed(bot *tgbotapi.BotAPI, ticket *Ticket, closed bool) {
+	sendOutbox(bot, topicClosedOutbox(ticket, closed))
+}
+
+// topicClosedOutbox — запросы закрытия или открытия темы тикета с сообщением в ней
+func topicClosedOutbox(ticket *Ticket, closed bool) []outMessage {
+	if !supportGroupEnabled() || ticket.TopicID == 0 {
+		return nil
+	}
+	endpoint, note := "closeForumTopic", "🔒 Тикет закрыт"
+	if !closed {
+		endpoint, note = "reopenForumTopic", "🔓 Тикет снова открыт"
+	}
+	params := tgbotapi.Params{}
+	params.AddNonZero64("chat_id", supportGroupChatID)
+	params.AddNonZero("message_thread_id", ticket.TopicID)
+	toggle := outMessage{Endpoint: endpoint, Params: params, Ticket: ticket}
+	post := outMessage{TopicID: ticket.TopicID, Text: note, Ticket: ticket}
+	if closed {
+		// Сообщение отправляем до закрытия темы
+		return []outMessage{post, toggle}
+	}
+	return []outMessage{toggle, post}
+}
+
+// handleSupportGroupMessage направляет сообщения менеджеров из темы тикета клиенту.
+// Библиотека не передает message_thread_id, поэтому тема определяется по reply: сообщения
+// в теме ссылаются на служебное сообщение ее создания (его ID совпадает с ID темы).
+func handleSupportGroupMessage(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
+	if message.From == nil || !isManagerUser(message.From) || message.ReplyToMessage == nil {
+		return
+	}
+
+	var ticketID, quoteMessageID int
+	if id, ok := topicTickets[message.ReplyToMessage.MessageID]; ok {
+		ticketID = id
+	} else if ref, ok := lookupReplyTarget(message); ok {
+		ticketID, quoteMessageID = ref.TicketID, ref.MessageID
+	} else {
+		return
+	}
+
+	ticket, exists := tickets[ticketID]
+	if !exists || ticket.TopicID == 0 {
+		return
+	}
+	// Заметки остаются в теме и клиенту не отправляются
+	if isNoteCommand(message.Text) {
+		addTopicNote(bot, message, ticket)
+		return
+	}
+	if handleRecallCommand(bot, message, ticket) {
+		return
+	}
+	sendManagerReply(bot, message, ticket, quoteMessageID)
+}
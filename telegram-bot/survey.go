@@ -0,0 +1,685 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// Шаги опроса подбора размера
+const (
+	stepProduct  = 1 // выбор товара
+	stepMeasure  = 2 // ввод замеров анкеты выбранного товара (см. garmentTypes)
+	stepOversize = 3 // вопрос об оверсайзе
+	stepSummary  = 4 // сводка ответов перед результатом
+)
+
+// surveyAllProducts — значение SelectedTee для подбора сразу по всему каталогу
+const surveyAllProducts = "all"
+
+// lastMeasurements хранит замеры последнего завершенного опроса (для повторного подбора без опроса)
+var lastMeasurements = make(map[int64]*UserState)
+
+// guidesDir — папка с иллюстрациями «Как измерить?» (по подпапке на замер: ./guides/chest/1.jpg)
+const guidesDir = "./guides"
+
+// quickPick — кнопка быстрого выбора значения замера
+type quickPick struct {
+	Label string
+	Value int
+}
+
+// measurementStep описывает один шаг ввода замера
+type measurementStep struct {
+	Key        string // "height", "chest", "waist", ...
+	Icon       string
+	Name       string // название в сводке
+	Prompt     string // вопрос клиенту
+	Retry      string // подсказка при нечисловом вводе
+	OutOfRange string // подсказка при выходе за диапазон
+	Min        int
+	Max        int
+	QuickPicks []quickPick
+	Guide      string       // текст инструкции «Как измерить?»
+	GuideDir   string       // папка с иллюстрациями (по умолчанию guidesDir/Key)
+	FromItem   *itemMeasure // альтернативный замер по своей вещи (nil если недоступен)
+}
+
+// itemMeasure описывает замер по вещи клиента и перевод его в замер тела
+type itemMeasure struct {
+	Prompt  string
+	Retry   string
+	Min     int
+	Max     int
+	Convert func(int) int
+}
+
+// surveyPosition — позиция в последовательности шагов опроса
+type surveyPosition struct {
+	Step       int
+	MeasureIdx int
+}
+
+// surveySequence возвращает шаги опроса для текущего состояния (оверсайз — только если доступен)
+func surveySequence(state *UserState) []surveyPosition {
+	seq := []surveyPosition{{Step: stepProduct}}
+	for i := range surveyMeasurementsFor(state) {
+		seq = append(seq, surveyPosition{Step: stepMeasure, MeasureIdx: i})
+	}
+	if surveyAllowsOversize(state) {
+		seq = append(seq, surveyPosition{Step: stepOversize})
+	}
+	return append(seq, surveyPosition{Step: stepSummary})
+}
+
+// surveyMeasurementsFor возвращает замеры анкеты выбранного товара;
+// для подбора по каталогу — объединение анкет всех товаров
+func surveyMeasurementsFor(state *UserState) []measurementStep {
+	var keys []string
+	if state.SelectedTee == surveyAllProducts {
+		for i := range products {
+			for _, k := range productGarment(i).Measurements {
+				if !containsString(keys, k) {
+					keys = append(keys, k)
+				}
+			}
+		}
+	} else if idx, ok := surveyProductIndex(state); ok {
+		keys = productGarment(idx).Measurements
+	} else {
+		keys = garmentTypes[defaultGarmentType].Measurements
+	}
+	steps := make([]measurementStep, 0, len(keys))
+	for _, k := range keys {
+		steps = append(steps, measurementSteps[k])
+	}
+	return steps
+}
+
+// sameMeasurementSteps проверяет, что анкеты состоят из одних и тех же замеров в том же порядке
+func sameMeasurementSteps(a, b []measurementStep) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key {
+			return false
+		}
+	}
+	return true
+}
+
+// surveyProductIndex возвращает индекс выбранного товара (false для подбора по каталогу)
+func surveyProductIndex(state *UserState) (int, bool) {
+	idx, err := strconv.Atoi(state.SelectedTee)
+	if err != nil || idx < 0 || idx >= len(products) {
+		return 0, false
+	}
+	return idx, true
+}
+
+// surveyProductName возвращает название выбранного товара для сводки и тикетов
+func surveyProductName(state *UserState) string {
+	if state.SelectedTee == surveyAllProducts {
+		return "Все товары"
+	}
+	if idx, ok := surveyProductIndex(state); ok {
+		return products[idx].Name
+	}
+	return "—"
+}
+
+// productAllowsOversize: оверсайз доступен, если он есть у товара и в анкете его типа
+func productAllowsOversize(idx int) bool {
+	return idx >= 0 && idx < len(products) && products[idx].Oversize && productGarment(idx).Oversize
+}
+
+func surveyAllowsOversize(state *UserState) bool {
+	if state.SelectedTee == surveyAllProducts {
+		for i := range products {
+			if productAllowsOversize(i) {
+				return true
+			}
+		}
+		return false
+	}
+	idx, ok := surveyProductIndex(state)
+	return ok && productAllowsOversize(idx)
+}
+
+func surveyValue(state *UserState, key string) int {
+	switch key {
+	case "height":
+		return state.Height
+	case "chest":
+		return state.ChestSize
+	}
+	return state.Measurements[key]
+}
+
+func setSurveyValue(state *UserState, key string, value int) {
+	switch key {
+	case "height":
+		state.Height = value
+	case "chest":
+		state.ChestSize = value
+	default:
+		if state.Measurements == nil {
+			state.Measurements = make(map[string]int)
+		}
+		state.Measurements[key] = value
+	}
+}
+
+// surveyValues возвращает все ответы на замеры в виде key -> значение
+func surveyValues(state *UserState) map[string]int {
+	values := make(map[string]int, len(state.Measurements)+2)
+	for k, v := range state.Measurements {
+		values[k] = v
+	}
+	if state.Height > 0 {
+		values["height"] = state.Height
+	}
+	if state.ChestSize > 0 {
+		values["chest"] = state.ChestSize
+	}
+	return values
+}
+
+// recommendForProduct подбирает размер товара по правилам его типа
+func recommendForProduct(idx int, state *UserState) sizeRecommendation {
+	return productGarment(idx).Recommend(surveyValues(state), state.Oversize && productAllowsOversize(idx))
+}
+
+// surveyAnswered проверяет, дан ли ответ на шаг опроса
+func surveyAnswered(state *UserState, pos surveyPosition) bool {
+	switch pos.Step {
+	case stepProduct:
+		return state.SelectedTee != ""
+	case stepMeasure:
+		steps := surveyMeasurementsFor(state)
+		return pos.MeasureIdx < len(steps) && surveyValue(state, steps[pos.MeasureIdx].Key) > 0
+	case stepOversize:
+		return state.OversizeAnswered
+	}
+	return true
+}
+
+func surveyHasAnswers(state *UserState) bool {
+	return state.SelectedTee != "" || state.Height > 0 || state.ChestSize > 0 || len(state.Measurements) > 0
+}
+
+func surveyIndex(seq []surveyPosition, state *UserState) int {
+	for i, pos := range seq {
+		if pos.Step == state.Step && (pos.Step != stepMeasure || pos.MeasureIdx == state.MeasureIdx) {
+			return i
+		}
+	}
+	return 0
+}
+
+// advanceSurvey переходит к следующему шагу (или к сводке, если клиент правил ответ)
+func advanceSurvey(bot *tgbotapi.BotAPI, chatID int64, state *UserState) {
+	seq := surveySequence(state)
+	next := seq[len(seq)-1]
+	if !state.Editing {
+		for _, pos := range seq[surveyIndex(seq, state)+1:] {
+			if pos.Step == stepSummary || !surveyAnswered(state, pos) {
+				next = pos
+				break
+			}
+		}
+	}
+	state.Step = next.Step
+	state.MeasureIdx = next.MeasureIdx
+	state.Editing = false
+	state.ItemMode = false
+	askSurveyStep(bot, chatID, state)
+}
+
+// askSurveyStep отправляет вопрос текущего шага
+func askSurveyStep(bot *tgbotapi.BotAPI, chatID int64, state *UserState) {
+	switch state.Step {
+	case stepProduct:
+		sendProductChoice(bot, chatID, state)
+	case stepMeasure:
+		steps := surveyMeasurementsFor(state)
+		if state.MeasureIdx >= len(steps) {
+			state.Step = stepSummary
+			showSurveySummary(bot, chatID, state)
+			return
+		}
+		askMeasurement(bot, chatID, state, steps[state.MeasureIdx])
+	case stepOversize:
+		askOversizeQuestion(bot, chatID, state)
+	case stepSummary:
+		showSurveySummary(bot, chatID, state)
+	}
+}
+
+// askMeasurement отправляет вопрос о замере: быстрые варианты, инструкция и замер по своей вещи
+func askMeasurement(bot *tgbotapi.BotAPI, chatID int64, state *UserState, step measurementStep) {
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var text string
+	if state.ItemMode && step.FromItem != nil {
+		text = step.FromItem.Prompt
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❓ Как измерить?", "survey_guide"),
+			tgbotapi.NewInlineKeyboardButtonData("📏 Ввести замер тела", "survey_body"),
+		))
+	} else {
+		text = step.Prompt
+		if v := surveyValue(state, step.Key); v > 0 {
+			text += fmt.Sprintf("\n\nТекущий ответ: %d см", v)
+		}
+		text += "\n\nВведите число или выберите диапазон:"
+		for _, qp := range step.QuickPicks {
+			button := tgbotapi.NewInlineKeyboardButtonData(qp.Label, fmt.Sprintf("survey_pick_%d", qp.Value))
+			if len(rows) == 0 || len(rows[len(rows)-1]) >= 3 {
+				rows = append(rows, []tgbotapi.InlineKeyboardButton{button})
+			} else {
+				rows[len(rows)-1] = append(rows[len(rows)-1], button)
+			}
+		}
+		helpRow := []tgbotapi.InlineKeyboardButton{
+			tgbotapi.NewInlineKeyboardButtonData("❓ Как измерить?", "survey_guide"),
+		}
+		if step.FromItem != nil {
+			helpRow = append(helpRow, tgbotapi.NewInlineKeyboardButtonData("👕 По своей вещи", "survey_item"))
+		}
+		rows = append(rows, helpRow)
+	}
+	rows = append(rows, surveyNavRow(state))
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	bot.Send(msg)
+}
+
+// sendMeasurementGuide отправляет инструкцию «Как измерить?»: текст и иллюстрации из папки замера
+func sendMeasurementGuide(bot *tgbotapi.BotAPI, chatID int64, step measurementStep) {
+	text := step.Guide
+	if text == "" {
+		text = "Инструкция для этого замера пока не добавлена. Если сомневаетесь, напишите менеджеру."
+	}
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❓ Как измерить: %s\n\n%s", strings.ToLower(step.Name), text)))
+
+	dir := step.GuideDir
+	if dir == "" {
+		dir = filepath.Join(guidesDir, step.Key)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	var images []string
+	for _, e := range entries {
+		switch strings.ToLower(filepath.Ext(e.Name())) {
+		case ".jpg", ".jpeg", ".png":
+			images = append(images, filepath.Join(dir, e.Name()))
+		}
+	}
+	sort.Strings(images)
+	if len(images) > 10 {
+		images = images[:10]
+	}
+
+	if len(images) == 1 {
+		if _, err := bot.Send(tgbotapi.NewPhoto(chatID, tgbotapi.FilePath(images[0]))); err != nil {
+			log.Printf("Ошибка отправки иллюстрации %s: %v", images[0], err)
+		}
+	} else if len(images) > 1 {
+		media := make([]interface{}, 0, len(images))
+		for _, path := range images {
+			media = append(media, tgbotapi.NewInputMediaPhoto(tgbotapi.FilePath(path)))
+		}
+		if _, err := bot.SendMediaGroup(tgbotapi.NewMediaGroup(chatID, media)); err != nil {
+			log.Printf("Ошибка отправки иллюстраций из %s: %v", dir, err)
+		}
+	}
+}
+
+// surveyNavRow — ряд навигации: назад, изменить ответы, начать заново
+func surveyNavRow(state *UserState) []tgbotapi.InlineKeyboardButton {
+	row := []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("◀️ Назад", "survey_back"),
+	}
+	if surveyHasAnswers(state) {
+		row = append(row,
+			tgbotapi.NewInlineKeyboardButtonData("✏️ Изменить", "survey_edit"),
+			tgbotapi.NewInlineKeyboardButtonData("🔄 Заново", "survey_restart"),
+		)
+	}
+	return row
+}
+
+// showSurveySummary показывает все ответы с возможностью изменить любой из них
+func showSurveySummary(bot *tgbotapi.BotAPI, chatID int64, state *UserState) {
+	var text strings.Builder
+	text.WriteString("📋 Проверьте ответы:\n\n")
+
+	text.WriteString(fmt.Sprintf("👕 Товар: %s\n", surveyProductName(state)))
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	addEdit := func(label, data string) {
+		button := tgbotapi.NewInlineKeyboardButtonData("✏️ "+label, data)
+		if len(rows) == 0 || len(rows[len(rows)-1]) >= 2 {
+			rows = append(rows, []tgbotapi.InlineKeyboardButton{button})
+		} else {
+			rows[len(rows)-1] = append(rows[len(rows)-1], button)
+		}
+	}
+	addEdit("Товар", "survey_change_product")
+
+	for _, step := range surveyMeasurementsFor(state) {
+		value := "—"
+		if v := surveyValue(state, step.Key); v > 0 {
+			value = fmt.Sprintf("%d см", v)
+		}
+		text.WriteString(fmt.Sprintf("%s %s: %s\n", step.Icon, step.Name, value))
+		addEdit(step.Name, "survey_change_"+step.Key)
+	}
+
+	if surveyAllowsOversize(state) {
+		value := "—"
+		if state.OversizeAnswered {
+			value = "Нет"
+			if state.Oversize {
+				value = "Да"
+			}
+		}
+		text.WriteString(fmt.Sprintf("👕 Оверсайз: %s\n", value))
+		addEdit("Оверсайз", "survey_change_oversize")
+	}
+
+	complete := true
+	for _, pos := range surveySequence(state) {
+		if !surveyAnswered(state, pos) {
+			complete = false
+			break
+		}
+	}
+	if complete {
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Показать результат", "survey_confirm"),
+		))
+	} else {
+		text.WriteString("\nЕсть вопросы без ответа.")
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("▶️ Продолжить", "survey_continue"),
+		))
+	}
+	rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔄 Начать заново", "survey_restart"),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	bot.Send(msg)
+}
+
+// handleSurveyCallback обрабатывает кнопки навигации опроса (survey_*)
+func handleSurveyCallback(bot *tgbotapi.BotAPI, chatID int64, data string) {
+	if data == "survey_restart" {
+		startSurvey(bot, chatID)
+		return
+	}
+
+	state, exists := userStates[chatID]
+	if !exists {
+		// Опрос уже завершен или сброшен — начинаем заново
+		startSurvey(bot, chatID)
+		return
+	}
+
+	// Любая навигация выключает режим замера по своей вещи
+	if data != "survey_guide" && data != "survey_item" {
+		state.ItemMode = false
+	}
+
+	switch {
+	case data == "survey_guide", data == "survey_item", data == "survey_body":
+		steps := surveyMeasurementsFor(state)
+		if state.Step != stepMeasure || state.MeasureIdx >= len(steps) {
+			return
+		}
+		step := steps[state.MeasureIdx]
+		switch data {
+		case "survey_guide":
+			sendMeasurementGuide(bot, chatID, step)
+		case "survey_item":
+			state.ItemMode = step.FromItem != nil
+		}
+		askMeasurement(bot, chatID, state, step)
+	case data == "survey_back":
+		// Отмена правки — возврат к сводке
+		if state.Editing {
+			state.Editing = false
+			state.Step = stepSummary
+			askSurveyStep(bot, chatID, state)
+			return
+		}
+		seq := surveySequence(state)
+		idx := surveyIndex(seq, state)
+		if idx == 0 {
+			delete(userStates, chatID)
+			sendMainMenu(bot, chatID)
+			return
+		}
+		prev := seq[idx-1]
+		state.Step = prev.Step
+		state.MeasureIdx = prev.MeasureIdx
+		askSurveyStep(bot, chatID, state)
+	case data == "survey_edit":
+		state.Editing = false
+		state.Step = stepSummary
+		askSurveyStep(bot, chatID, state)
+	case data == "survey_continue":
+		state.Editing = false
+		for _, pos := range surveySequence(state) {
+			if !surveyAnswered(state, pos) {
+				state.Step = pos.Step
+				state.MeasureIdx = pos.MeasureIdx
+				break
+			}
+		}
+		askSurveyStep(bot, chatID, state)
+	case data == "survey_confirm":
+		showRecommendations(bot, chatID, state)
+		delete(userStates, chatID)
+	case strings.HasPrefix(data, "survey_pick_"):
+		if state.Step != stepMeasure {
+			return
+		}
+		value, err := strconv.Atoi(strings.TrimPrefix(data, "survey_pick_"))
+		steps := surveyMeasurementsFor(state)
+		if err != nil || state.MeasureIdx >= len(steps) {
+			return
+		}
+		setSurveyValue(state, steps[state.MeasureIdx].Key, value)
+		advanceSurvey(bot, chatID, state)
+	case strings.HasPrefix(data, "survey_change_"):
+		key := strings.TrimPrefix(data, "survey_change_")
+		state.Editing = true
+		switch key {
+		case "product":
+			state.Step = stepProduct
+		case "oversize":
+			state.Step = stepOversize
+		default:
+			found := false
+			for i, step := range surveyMeasurementsFor(state) {
+				if step.Key == key {
+					state.Step = stepMeasure
+					state.MeasureIdx = i
+					found = true
+					break
+				}
+			}
+			if !found {
+				log.Printf("Неизвестный шаг опроса для изменения: %s", key)
+				state.Editing = false
+				state.Step = stepSummary
+			}
+		}
+		askSurveyStep(bot, chatID, state)
+	}
+}
+
+// extraMeasurements возвращает копию замеров кроме роста и обхвата груди (nil если их нет)
+func extraMeasurements(state *UserState) map[string]int {
+	if len(state.Measurements) == 0 {
+		return nil
+	}
+	values := make(map[string]int, len(state.Measurements))
+	for k, v := range state.Measurements {
+		values[k] = v
+	}
+	return values
+}
+
+// rememberMeasurements сохраняет замеры завершенного опроса
+func rememberMeasurements(chatID int64, state *UserState) {
+	saved := *state
+	saved.Editing = false
+	saved.Measurements = extraMeasurements(state)
+	lastMeasurements[chatID] = &saved
+}
+
+// productHasSize проверяет, есть ли в наличии хотя бы один размер из маркировки (например, "XL-2XL")
+func productHasSize(product Product, mark string) bool {
+	normalize := func(size string) string {
+		size = strings.ToUpper(strings.TrimSpace(size))
+		if size == "2XL" {
+			return "XXL"
+		}
+		return size
+	}
+	for _, part := range strings.Split(mark, "-") {
+		for _, size := range product.Sizes {
+			if normalize(part) == normalize(size) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// showAllProductsRecommendations показывает рекомендованный размер, наличие и ссылку для каждого товара
+func showAllProductsRecommendations(bot *tgbotapi.BotAPI, chatID int64, state *UserState) {
+	log.Printf("Показываю подбор по всему каталогу для чата %d", chatID)
+
+	var text strings.Builder
+	text.WriteString("🧾 Подбор по всему каталогу\n\n")
+	for _, step := range surveyMeasurementsFor(state) {
+		if v := surveyValue(state, step.Key); v > 0 {
+			text.WriteString(fmt.Sprintf("%s %s: %d см\n", step.Icon, step.Name, v))
+		}
+	}
+	if state.Oversize {
+		text.WriteString("👕 Оверсайз: Да (где доступен)\n")
+	}
+	text.WriteString("\n")
+
+	var rows [][]tgbotapi.InlineKeyboardButton
+	var flagged []string
+	for i, product := range products {
+		oversize := state.Oversize && productAllowsOversize(i)
+		rec := recommendForProduct(i, state)
+		mark, ru := rec.Mark, rec.RU
+
+		availability := "✅ В наличии"
+		if !productHasSize(product, mark) {
+			availability = fmt.Sprintf("❌ Нет в наличии (есть: %s)", strings.Join(product.Sizes, ", "))
+		}
+		oversizeNote := ""
+		if oversize {
+			oversizeNote = " · оверсайз"
+		}
+		confidenceNote := ""
+		if rec.Confidence != sizeConfidenceHigh {
+			confidenceNote = " ⚠️"
+			if !containsString(flagged, rec.Reason) {
+				flagged = append(flagged, rec.Reason)
+			}
+		}
+		text.WriteString(fmt.Sprintf("%d. %s (%s)\nМаркировка: %s · RU %s%s%s\n%s\n\n",
+			i+1, product.Name, productGarment(i).Name, mark, ru, oversizeNote, confidenceNote, availability))
+
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonURL(fmt.Sprintf("🛒 %d. Купить", i+1), product.Link),
+		))
+	}
+
+	if len(flagged) > 0 {
+		text.WriteString("⚠️ " + strings.Join(flagged, "\n⚠️ ") + "\nРекомендуем уточнить размер у менеджера.")
+		rows = append(rows, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("💬 Уточнить у менеджера", "size_clarify"),
+		))
+	}
+
+	rows = append(rows,
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Подобрать еще", "select"),
+			tgbotapi.NewInlineKeyboardButtonData("Каталог", "browse"),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Связаться с менеджером", "contact_manager"),
+		),
+	)
+
+	msg := tgbotapi.NewMessage(chatID, text.String())
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(rows...)
+	if _, err := bot.Send(msg); err != nil {
+		log.Printf("Ошибка отправки подбора по каталогу: %v", err)
+	}
+
+	// Размеры у товаров разные (по ним разные таблицы), единого рекомендованного размера нет
+	rememberMeasurements(chatID, state)
+	saveSurveyToTicket(chatID, state, false, "Не определен")
+	recordClientSurvey(chatID, state, false, "")
+}
+
+// showSavedAllProductsRecommendations показывает подбор по каталогу по последним сохраненным замерам
+func showSavedAllProductsRecommendations(bot *tgbotapi.BotAPI, chatID int64) {
+	saved, ok := lastMeasurements[chatID]
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(chatID, "Замеры не найдены. Пройдите подбор размера."))
+		startSurvey(bot, chatID)
+		return
+	}
+	showAllProductsRecommendations(bot, chatID, saved)
+}
+
+func containsString(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// surveyRecommendation возвращает название товара и подбор по сохраненным замерам;
+// для подбора по каталогу — первый товар с неточным подбором (если есть)
+func surveyRecommendation(state *UserState) (string, sizeRecommendation) {
+	if state.SelectedTee == surveyAllProducts {
+		for i, product := range products {
+			if rec := recommendForProduct(i, state); rec.Confidence != sizeConfidenceHigh {
+				return product.Name, rec
+			}
+		}
+		return "Все товары", sizeRecommendation{Mark: "Не определен", Confidence: sizeConfidenceHigh}
+	}
+	idx, ok := surveyProductIndex(state)
+	if !ok {
+		return "Не определен", recommendSize(state.ChestSize, false)
+	}
+	return products[idx].Name, recommendForProduct(idx, state)
+}
@@ -0,0 +1,466 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ReplyTemplate — сохраненный ответ менеджера (шаблон) с подстановками из тикета
+type ReplyTemplate struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+const templatesStoreFile = "templates.json"
+
+var replyTemplates []ReplyTemplate
+var nextTemplateID = 1
+
+// templateInput — ввод нового шаблона или изменение существующего (TemplateID != 0)
+type templateInput struct {
+	TemplateID int
+	Step       string // "title", "text"
+	Title      string
+}
+
+var templateInputState = make(map[int64]*templateInput) // chatID менеджера -> ввод шаблона
+
+// templateDraft — ответ по шаблону, который менеджер правит перед отправкой
+type templateDraft struct {
+	TicketID   int
+	TemplateID int
+	Text       string
+}
+
+var templateDraftState = make(map[int64]*templateDraft) // chatID менеджера -> черновик ответа по шаблону
+
+// templatePlaceholders — поддерживаемые подстановки и их значения из тикета
+var templatePlaceholders = []struct {
+	Key   string
+	Title string
+	Value func(t *Ticket) string
+}{
+	{"{name}", "имя клиента", func(t *Ticket) string { return strings.TrimSpace(t.FirstName + " " + t.LastName) }},
+	{"{ticket_id}", "номер тикета", func(t *Ticket) string { return strconv.Itoa(t.ID) }},
+	{"{recommended_size}", "рекомендованный размер", func(t *Ticket) string {
+		if t.RecommendedSize == "Не определен" {
+			return ""
+		}
+		return t.RecommendedSize
+	}},
+	{"{product}", "товар", func(t *Ticket) string { return t.Product }},
+}
+
+// defaultReplyTemplates — шаблоны, с которыми бот стартует, если templates.json еще нет
+var defaultReplyTemplates = []ReplyTemplate{
+	{1, "Сроки доставки", "{name}, заказ отправляем в течение 1–2 рабочих дней после оплаты, доставка по России занимает 3–7 дней. Трек-номер пришлем, как только посылка уйдет."},
+	{2, "Обмен размера", "{name}, если размер не подошел, его можно обменять в течение 14 дней: вещь должна быть без следов носки и с бирками. Напишите, на какой размер хотите обменять, и мы подскажем, как отправить посылку."},
+	{3, "Рекомендация размера", "{name}, по вашим замерам для «{product}» рекомендуем размер {recommended_size}."},
+}
+
+// loadTemplates загружает шаблоны ответов из файла
+func loadTemplates() {
+	data, err := os.ReadFile(templatesStoreFile)
+	if err != nil {
+		replyTemplates = append([]ReplyTemplate(nil), defaultReplyTemplates...)
+		nextTemplateID = len(replyTemplates) + 1
+		log.Printf("Файл шаблонов не найден, используем шаблоны по умолчанию")
+		return
+	}
+	if err := json.Unmarshal(data, &replyTemplates); err != nil {
+		log.Printf("Ошибка чтения %s: %v", templatesStoreFile, err)
+		return
+	}
+	for _, t := range replyTemplates {
+		if t.ID >= nextTemplateID {
+			nextTemplateID = t.ID + 1
+		}
+	}
+	log.Printf("Загружено %d шаблонов ответов", len(replyTemplates))
+}
+
+func saveTemplates() {
+	data, err := json.MarshalIndent(replyTemplates, "", "  ")
+	if err != nil {
+		log.Printf("Ошибка сериализации шаблонов: %v", err)
+		return
+	}
+	if err := os.WriteFile(templatesStoreFile, data, 0644); err != nil {
+		log.Printf("Ошибка записи %s: %v", templatesStoreFile, err)
+	}
+}
+
+func findTemplate(id int) *ReplyTemplate {
+	for i := range replyTemplates {
+		if replyTemplates[i].ID == id {
+			return &replyTemplates[i]
+		}
+	}
+	return nil
+}
+
+// fillTemplate подставляет данные тикета в шаблон и возвращает незаполненные подстановки
+func fillTemplate(text string, ticket *Ticket) (string, []string) {
+	var missing []string
+	for _, p := range templatePlaceholders {
+		if !strings.Contains(text, p.Key) {
+			continue
+		}
+		value := p.Value(ticket)
+		if value == "" {
+			missing = append(missing, p.Title)
+		}
+		text = strings.ReplaceAll(text, p.Key, value)
+	}
+	return text, missing
+}
+
+// placeholdersHelp — подсказка о доступных подстановках
+func placeholdersHelp() string {
+	lines := make([]string, 0, len(templatePlaceholders))
+	for _, p := range templatePlaceholders {
+		lines = append(lines, fmt.Sprintf("%s — %s", p.Key, p.Title))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// showTemplatesMenu показывает менеджеру библиотеку шаблонов
+func showTemplatesMenu(bot *tgbotapi.BotAPI, chatID int64) {
+	delete(templateInputState, chatID)
+
+	text := "📝 Шаблоны ответов\n\n"
+	if len(replyTemplates) == 0 {
+		text += "Шаблонов пока нет."
+	} else {
+		text += "Выберите шаблон, чтобы посмотреть, изменить или удалить его."
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for _, t := range replyTemplates {
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(t.Title, fmt.Sprintf("template_view_%d", t.ID)),
+		))
+	}
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("➕ Добавить шаблон", "template_add"),
+	))
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back_to_manager_menu"),
+	))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	bot.Send(msg)
+}
+
+// showTemplate показывает текст шаблона с подстановками
+func showTemplate(bot *tgbotapi.BotAPI, chatID int64, id int) {
+	t := findTemplate(id)
+	if t == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Шаблон не найден"))
+		return
+	}
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("📝 %s\n\n%s", t.Title, t.Text))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✏️ Изменить", fmt.Sprintf("template_change_%d", id)),
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Удалить", fmt.Sprintf("template_delete_%d", id)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔙 К шаблонам", "manager_templates"),
+		),
+	)
+	bot.Send(msg)
+}
+
+// startTemplateInput запрашивает название нового шаблона или новый текст существующего
+func startTemplateInput(bot *tgbotapi.BotAPI, chatID int64, id int) {
+	input := &templateInput{TemplateID: id, Step: "title"}
+	text := "➕ Новый шаблон\n\nОтправьте название шаблона (оно будет на кнопке)."
+	if id != 0 {
+		t := findTemplate(id)
+		if t == nil {
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Шаблон не найден"))
+			return
+		}
+		input.Step, input.Title = "text", t.Title
+		text = fmt.Sprintf("✏️ Шаблон «%s»\n\nОтправьте новый текст шаблона.", t.Title)
+	}
+	templateInputState[chatID] = input
+
+	text += "\n\nДоступные подстановки:\n" + placeholdersHelp() + "\n\nИспользуйте /cancel для отмены"
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", "manager_templates"),
+		),
+	)
+	bot.Send(msg)
+}
+
+// handleTemplateInput обрабатывает ввод названия и текста шаблона
+func handleTemplateInput(bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
+	chatID := message.Chat.ID
+	input, ok := templateInputState[chatID]
+	if !ok {
+		return false
+	}
+
+	text := strings.TrimSpace(message.Text)
+	if text == "/cancel" {
+		showTemplatesMenu(bot, chatID)
+		return true
+	}
+	if text == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, "Отправьте текст сообщением или /cancel для отмены"))
+		return true
+	}
+
+	if input.Step == "title" {
+		input.Title = text
+		input.Step = "text"
+		bot.Send(tgbotapi.NewMessage(chatID, "Теперь отправьте текст шаблона."))
+		return true
+	}
+
+	delete(templateInputState, chatID)
+	id := input.TemplateID
+	if t := findTemplate(id); t != nil {
+		t.Text = text
+	} else {
+		id = nextTemplateID
+		nextTemplateID++
+		replyTemplates = append(replyTemplates, ReplyTemplate{ID: id, Title: input.Title, Text: text})
+	}
+	saveTemplates()
+	log.Printf("Менеджер %d сохранил шаблон #%d «%s»", chatID, id, input.Title)
+
+	bot.Send(tgbotapi.NewMessage(chatID, "✅ Шаблон сохранен"))
+	showTemplate(bot, chatID, id)
+	return true
+}
+
+// confirmDeleteTemplate спрашивает подтверждение перед удалением шаблона
+func confirmDeleteTemplate(bot *tgbotapi.BotAPI, chatID int64, id int) {
+	t := findTemplate(id)
+	if t == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Шаблон не найден"))
+		return
+	}
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🗑 Удалить шаблон «%s»?\n\n%s", t.Title, t.Text))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🗑 Удалить", fmt.Sprintf("template_remove_%d", id)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("template_view_%d", id)),
+		),
+	)
+	bot.Send(msg)
+}
+
+func deleteTemplate(bot *tgbotapi.BotAPI, chatID int64, id int) {
+	for i, t := range replyTemplates {
+		if t.ID == id {
+			replyTemplates = append(replyTemplates[:i], replyTemplates[i+1:]...)
+			saveTemplates()
+			log.Printf("Менеджер %d удалил шаблон #%d «%s»", chatID, id, t.Title)
+			bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("🗑 Шаблон «%s» удален", t.Title)))
+			break
+		}
+	}
+	showTemplatesMenu(bot, chatID)
+}
+
+// showTicketTemplates предлагает выбрать шаблон для ответа в тикет
+func showTicketTemplates(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+	if _, exists := tickets[ticketID]; !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	if len(replyTemplates) == 0 {
+		msg := tgbotapi.NewMessage(chatID, "📝 Шаблонов пока нет — добавьте их в меню менеджера")
+		msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(
+				tgbotapi.NewInlineKeyboardButtonData("🔙 К тикету", fmt.Sprintf("ticket_view_%d", ticketID)),
+			),
+		)
+		bot.Send(msg)
+		return
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("📝 Выберите шаблон для ответа в тикет #%d:", ticketID))
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for _, t := range replyTemplates {
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(t.Title, fmt.Sprintf("template_pick_%d_%d", ticketID, t.ID)),
+		))
+	}
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔙 К тикету", fmt.Sprintf("ticket_view_%d", ticketID)),
+	))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	bot.Send(msg)
+}
+
+// previewTicketTemplate показывает шаблон с подставленными данными тикета перед отправкой
+func previewTicketTemplate(bot *tgbotapi.BotAPI, chatID int64, ticketID, id int) {
+	ticket, exists := tickets[ticketID]
+	t := findTemplate(id)
+	if !exists || t == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет или шаблон не найден"))
+		return
+	}
+
+	filled, missing := fillTemplate(t.Text, ticket)
+	text := fmt.Sprintf("👁 Предпросмотр ответа в тикет #%d:\n\n%s", ticketID, filled)
+	if len(missing) > 0 {
+		text += "\n\n⚠️ В тикете нет данных: " + strings.Join(missing, ", ") + " — проверьте текст перед отправкой"
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Отправить", fmt.Sprintf("template_send_%d_%d", ticketID, id)),
+			tgbotapi.NewInlineKeyboardButtonData("✏️ Изменить", fmt.Sprintf("template_edit_%d_%d", ticketID, id)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔙 К шаблонам", fmt.Sprintf("ticket_templates_%d", ticketID)),
+		),
+	)
+	bot.Send(msg)
+}
+
+// sendTicketTemplate отправляет клиенту заполненный шаблон от имени менеджера
+func sendTicketTemplate(bot *tgbotapi.BotAPI, chatID int64, ticketID, id int) {
+	ticket, exists := tickets[ticketID]
+	t := findTemplate(id)
+	if !exists || t == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет или шаблон не найден"))
+		return
+	}
+	filled, _ := fillTemplate(t.Text, ticket)
+	sendTemplateReply(bot, chatID, ticket, id, filled)
+}
+
+// sendTemplateReply отправляет ответ по шаблону и отмечает в тикете, из какого шаблона он составлен
+func sendTemplateReply(bot *tgbotapi.BotAPI, chatID int64, ticket *Ticket, templateID int, text string) {
+	reply := &tgbotapi.Message{Text: text, Chat: &tgbotapi.Chat{ID: chatID}, From: &tgbotapi.User{ID: chatID}}
+	if !sendManagerReply(bot, reply, ticket, 0) {
+		return
+	}
+	ticket.Messages[len(ticket.Messages)-1].TemplateID = templateID
+	saveTickets()
+	log.Printf("Менеджер %d отправил шаблон #%d в тикет #%d", chatID, templateID, ticket.ID)
+}
+
+// editTicketTemplate создает черновик ответа из шаблона: менеджер присылает исправленный текст
+// и подтверждает отправку
+func editTicketTemplate(bot *tgbotapi.BotAPI, chatID int64, ticketID, id int) {
+	ticket, exists := tickets[ticketID]
+	t := findTemplate(id)
+	if !exists || t == nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет или шаблон не найден"))
+		return
+	}
+	filled, _ := fillTemplate(t.Text, ticket)
+	templateDraftState[chatID] = &templateDraft{TicketID: ticketID, TemplateID: id, Text: filled}
+	showTemplateDraft(bot, chatID)
+}
+
+// showTemplateDraft показывает текущий текст черновика с кнопками отправки
+func showTemplateDraft(bot *tgbotapi.BotAPI, chatID int64) {
+	d := templateDraftState[chatID]
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("✏️ Черновик ответа в тикет #%d:\n\n%s\n\n"+
+		"Отправьте исправленный текст следующим сообщением — он заменит черновик. Клиент получит ответ только после нажатия «Отправить».\n\n"+
+		"Используйте /cancel для отмены", d.TicketID, d.Text))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("✅ Отправить", fmt.Sprintf("template_draft_%d", d.TicketID)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("ticket_view_%d", d.TicketID)),
+		),
+	)
+	bot.Send(msg)
+}
+
+// handleTemplateDraftInput заменяет текст черновика ответа по шаблону
+func handleTemplateDraftInput(bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
+	chatID := message.Chat.ID
+	d, ok := templateDraftState[chatID]
+	if !ok {
+		return false
+	}
+
+	text := strings.TrimSpace(message.Text)
+	if text == "/cancel" {
+		delete(templateDraftState, chatID)
+		showTicketDetails(bot, chatID, d.TicketID)
+		return true
+	}
+	if text == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, "Отправьте текст ответа сообщением или /cancel для отмены"))
+		return true
+	}
+	d.Text = text
+	showTemplateDraft(bot, chatID)
+	return true
+}
+
+// sendTemplateDraft отправляет клиенту подтвержденный черновик
+func sendTemplateDraft(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+	d, ok := templateDraftState[chatID]
+	if !ok || d.TicketID != ticketID {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Черновик не найден, выберите шаблон заново"))
+		return
+	}
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		delete(templateDraftState, chatID)
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	delete(templateDraftState, chatID)
+	sendTemplateReply(bot, chatID, ticket, d.TemplateID, d.Text)
+}
+
+// handleTemplateCallback обрабатывает кнопки библиотеки шаблонов и выбора шаблона в тикете
+func handleTemplateCallback(bot *tgbotapi.BotAPI, chatID int64, data string) {
+	if data == "template_add" {
+		startTemplateInput(bot, chatID, 0)
+		return
+	}
+
+	// template_<действие>_<ID шаблона> или template_<действие>_<ID тикета>_<ID шаблона>
+	parts := strings.Split(strings.TrimPrefix(data, "template_"), "_")
+	ids := make([]int, 0, 2)
+	for _, p := range parts[1:] {
+		id, err := strconv.Atoi(p)
+		if err != nil {
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Ошибка ID шаблона"))
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	switch {
+	case parts[0] == "view" && len(ids) == 1:
+		showTemplate(bot, chatID, ids[0])
+	case parts[0] == "change" && len(ids) == 1:
+		startTemplateInput(bot, chatID, ids[0])
+	case parts[0] == "delete" && len(ids) == 1:
+		confirmDeleteTemplate(bot, chatID, ids[0])
+	case parts[0] == "remove" && len(ids) == 1:
+		deleteTemplate(bot, chatID, ids[0])
+	case parts[0] == "draft" && len(ids) == 1:
+		sendTemplateDraft(bot, chatID, ids[0])
+	case parts[0] == "pick" && len(ids) == 2:
+		previewTicketTemplate(bot, chatID, ids[0], ids[1])
+	case parts[0] == "send" && len(ids) == 2:
+		sendTicketTemplate(bot, chatID, ids[0], ids[1])
+	case parts[0] == "edit" && len(ids) == 2:
+		editTicketTemplate(bot, chatID, ids[0], ids[1])
+	}
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"log"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// MessageRef — сообщение Telegram в конкретном чате
+type MessageRef struct {
+	ChatID    int64 `json:"chat_id"`
+	MessageID int   `json:"message_id"`
+}
+
+// ticketMessageRef — к какому тикету (и сообщению тикета) относится сообщение Telegram.
+// MessageID == 0 — сообщение относится к тикету целиком (карточка, напоминание).
+type ticketMessageRef struct {
+	TicketID  int
+	MessageID int
+}
+
+// telegramMessageIndex: сообщение Telegram -> тикет; восстанавливается из тикетов при загрузке
+var telegramMessageIndex = make(map[MessageRef]ticketMessageRef)
+
+// findTicketMessage возвращает сообщение тикета по его номеру
+func findTicketMessage(ticket *Ticket, messageID int) *Message {
+	for i := range ticket.Messages {
+		if ticket.Messages[i].ID == messageID {
+			return &ticket.Messages[i]
+		}
+	}
+	return nil
+}
+
+// findMovedMessage ищет сообщение, перенесенное из тикета ticketID (объединение, разделение),
+// по его прежнему номеру
+func findMovedMessage(ticketID, messageID int) (*Ticket, int) {
+	for _, t := range tickets {
+		for _, m := range t.Messages {
+			for _, alias := range m.Aliases {
+				if alias.TicketID == ticketID && alias.MessageID == messageID {
+					return t, m.ID
+				}
+			}
+		}
+	}
+	return nil, 0
+}
+
+// linkTicketNotification запоминает уведомление, относящееся к тикету целиком
+func linkTicketNotification(ticket *Ticket, sent tgbotapi.Message) {
+	if sent.MessageID == 0 || sent.Chat == nil {
+		return
+	}
+	ref := MessageRef{ChatID: sent.Chat.ID, MessageID: sent.MessageID}
+	ticket.Notifications = append(ticket.Notifications, ref)
+	telegramMessageIndex[ref] = ticketMessageRef{TicketID: ticket.ID}
+}
+
+// linkMessageMirror запоминает копию сообщения тикета, отправленную ботом в другой чат
+func linkMessageMirror(ticket *Ticket, messageID int, sent tgbotapi.Message) {
+	msg := findTicketMessage(ticket, messageID)
+	if msg == nil || sent.MessageID == 0 || sent.Chat == nil {
+		return
+	}
+	ref := MessageRef{ChatID: sent.Chat.ID, MessageID: sent.MessageID}
+	msg.Mirrors = append(msg.Mirrors, ref)
+	telegramMessageIndex[ref] = ticketMessageRef{TicketID: ticket.ID, MessageID: messageID}
+}
+
+// linkMessageOrigin запоминает исходное сообщение Telegram, из которого создано сообщение тикета
+func linkMessageOrigin(ticket *Ticket, messageID int, origin *tgbotapi.Message) {
+	msg := findTicketMessage(ticket, messageID)
+	if msg == nil || origin == nil || origin.MessageID == 0 {
+		// Сообщение сформировано ботом (ответ из шаблона, запрос на уточнение размера) — исходного сообщения нет
+		return
+	}
+	msg.TgMessageID = origin.MessageID
+	msg.TgChatID = origin.Chat.ID
+	telegramMessageIndex[MessageRef{ChatID: origin.Chat.ID, MessageID: origin.MessageID}] = ticketMessageRef{TicketID: ticket.ID, MessageID: messageID}
+}
+
+// rebuildTelegramMessageIndex восстанавливает индекс сообщений Telegram по сохраненным тикетам
+func rebuildTelegramMessageIndex() {
+	telegramMessageIndex = make(map[MessageRef]ticketMessageRef)
+	for _, t := range tickets {
+		for _, ref := range t.Notifications {
+			telegramMessageIndex[ref] = ticketMessageRef{TicketID: t.ID}
+		}
+		for _, m := range t.Messages {
+			if m.TgMessageID != 0 {
+				chatID := m.TgChatID
+				if chatID == 0 {
+					chatID = t.UserID
+					if m.IsFromManager {
+						chatID = m.SenderID
+					}
+				}
+				telegramMessageIndex[MessageRef{ChatID: chatID, MessageID: m.TgMessageID}] = ticketMessageRef{TicketID: t.ID, MessageID: m.ID}
+			}
+			for _, ref := range m.Mirrors {
+				telegramMessageIndex[ref] = ticketMessageRef{TicketID: t.ID, MessageID: m.ID}
+			}
+		}
+	}
+}
+
+// lookupReplyTarget определяет тикет по сообщению, на которое ответил пользователь
+func lookupReplyTarget(message *tgbotapi.Message) (ticketMessageRef, bool) {
+	if message.ReplyToMessage == nil {
+		return ticketMessageRef{}, false
+	}
+	ref, ok := telegramMessageIndex[MessageRef{ChatID: message.Chat.ID, MessageID: message.ReplyToMessage.MessageID}]
+	return ref, ok
+}
+
+// quoteTargetForClient возвращает ID сообщения в чате клиента, которое нужно процитировать в ответе менеджера:
+// указанное сообщение тикета или, если оно не задано, последнее сообщение клиента
+func quoteTargetForClient(ticket *Ticket, messageID int) int {
+	if messageID == 0 {
+		for i := len(ticket.Messages) - 1; i >= 0; i-- {
+			if !ticket.Messages[i].IsFromManager {
+				messageID = ticket.Messages[i].ID
+				break
+			}
+		}
+	}
+	msg := findTicketMessage(ticket, messageID)
+	if msg == nil {
+		return 0
+	}
+	if !msg.IsFromManager {
+		return msg.TgMessageID
+	}
+	// Сообщение менеджера — цитируем его копию в чате клиента
+	for _, ref := range msg.Mirrors {
+		if ref.ChatID == ticket.UserID {
+			return ref.MessageID
+		}
+	}
+	return 0
+}
+
+// handleManagerThreadReply направляет ответ менеджера (reply на уведомление) в соответствующий тикет
+func handleManagerThreadReply(bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
+	target, ok := lookupReplyTarget(message)
+	if !ok {
+		return false
+	}
+	ticket, exists := tickets[target.TicketID]
+	if !exists {
+		return false
+	}
+	log.Printf("Ответ менеджера %d через reply направлен в тикет #%d", message.Chat.ID, ticket.ID)
+	sendManagerReply(bot, message, ticket, target.MessageID)
+	return true
+}
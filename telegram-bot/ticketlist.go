@@ -0,0 +1,277 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const ticketsPerPage = 10
+
+// ticketSortOptions — варианты сортировки списка тикетов
+var ticketSortOptions = []ticketOption{
+	{"new", "🆕 Новые"},
+	{"activity", "🕐 Активность"},
+	{"waiting", "⏳ Дольше ждут"},
+	{"unanswered", "💬 Без ответа"},
+}
+
+const defaultTicketSort = "new"
+
+// ticketWaitingSince — с какого момента клиент ждет ответа (только для открытых тикетов)
+func ticketWaitingSince(ticket *Ticket) time.Time {
+	if ticket.Status != "open" {
+		return time.Time{}
+	}
+	return unansweredSince(ticket)
+}
+
+// sortTickets сортирует тикеты выбранным способом. При равенстве — новые сверху.
+func sortTickets(list []*Ticket, mode string) {
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		switch mode {
+		case "activity":
+			if !a.LastMessage.Equal(b.LastMessage) {
+				return a.LastMessage.After(b.LastMessage)
+			}
+		case "waiting", "unanswered":
+			wa, wb := ticketWaitingSince(a), ticketWaitingSince(b)
+			if wa.IsZero() != wb.IsZero() {
+				return !wa.IsZero()
+			}
+			if mode == "waiting" && !wa.Equal(wb) {
+				return wa.Before(wb)
+			}
+			if mode == "unanswered" && !a.LastMessage.Equal(b.LastMessage) {
+				return a.LastMessage.After(b.LastMessage)
+			}
+		}
+		return a.ID > b.ID
+	})
+}
+
+// filteredTicketList возвращает тикеты, прошедшие фильтры менеджера, в выбранном порядке
+func filteredTicketList(filter *ticketListFilter) []*Ticket {
+	var list []*Ticket
+	for _, ticket := range tickets {
+		if filter.matches(ticket) {
+			list = append(list, ticket)
+		}
+	}
+	sortTickets(list, filter.sortMode())
+	return list
+}
+
+// sortMode возвращает выбранную сортировку или сортировку по умолчанию
+func (f *ticketListFilter) sortMode() string {
+	if _, ok := findTicketOption(ticketSortOptions, f.Sort); ok {
+		return f.Sort
+	}
+	return defaultTicketSort
+}
+
+// ticketListLine — строка тикета в списке
+func ticketListLine(ticket *Ticket) string {
+	status := "🟢"
+	if ticket.Status == "closed" {
+		status = "🔴"
+	}
+
+	name := strings.TrimSpace(ticket.FirstName + " " + ticket.LastName)
+	if name == "" {
+		name = "Без имени"
+	}
+	username := ""
+	if ticket.Username != "" {
+		username = fmt.Sprintf(" (@%s)", ticket.Username)
+	}
+
+	// Приоритет и теги
+	if p := ticketPriority(ticket); p == "high" || p == "urgent" {
+		status += " " + strings.Fields(priorityText(p))[0]
+	}
+	if ticket.Undeliverable {
+		status += " ⛔"
+	}
+	tags := ""
+	if len(ticket.Tags) > 0 {
+		tags = " " + formatTags(ticket.Tags)
+	}
+
+	assignee := ""
+	if ticket.AssigneeID != 0 {
+		assignee = fmt.Sprintf(" | 🙋 %s", assigneeText(ticket))
+	}
+	waiting := ""
+	if since := ticketWaitingSince(ticket); !since.IsZero() {
+		waiting = fmt.Sprintf(" | ⏳ %s", formatWait(time.Since(since)))
+	}
+
+	return fmt.Sprintf("%s #%d %s%s%s\n🆔 %d | %s%s%s\n\n",
+		status, ticket.ID, name, username, tags, ticket.UserID, ticket.CreatedAt.Format("02.01 15:04"), assignee, waiting)
+}
+
+// renderTicketList формирует текущую страницу списка тикетов менеджера с кнопками
+func renderTicketList(chatID int64) (string, tgbotapi.InlineKeyboardMarkup) {
+	filter := ticketListFilterFor(chatID)
+	list := filteredTicketList(filter)
+
+	pages := (len(list) + ticketsPerPage - 1) / ticketsPerPage
+	if filter.Page >= pages {
+		filter.Page = pages - 1
+	}
+	if filter.Page < 0 {
+		filter.Page = 0
+	}
+	start := filter.Page * ticketsPerPage
+	end := start + ticketsPerPage
+	if end > len(list) {
+		end = len(list)
+	}
+	page := list[start:end]
+
+	var title string
+	switch filter.Status {
+	case "":
+		title = "🎫 Все тикеты"
+	case "open":
+		title = "🆕 Открытые тикеты"
+	case "closed":
+		title = "🔴 Закрытые тикеты"
+	default:
+		title = "🎫 Тикеты"
+	}
+	if d := filter.describe(); d != "" {
+		title += " [" + d + "]"
+	}
+
+	var text strings.Builder
+	text.WriteString(fmt.Sprintf("%s (%d):\n", title, len(list)))
+	sortOption, _ := findTicketOption(ticketSortOptions, filter.sortMode())
+	text.WriteString(fmt.Sprintf("↕️ Сортировка: %s\n\n", sortOption.Title))
+	if len(list) == 0 {
+		text.WriteString("📭 Нет тикетов")
+	}
+	for _, ticket := range page {
+		text.WriteString(ticketListLine(ticket))
+	}
+	if pages > 1 {
+		text.WriteString(fmt.Sprintf("📄 Страница %d из %d", filter.Page+1, pages))
+	}
+
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+
+	// Кнопки статуса
+	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("🎫 Все", "manager_tickets"),
+		tgbotapi.NewInlineKeyboardButtonData("🆕 Открытые", "manager_open_tickets"),
+		tgbotapi.NewInlineKeyboardButtonData("🔴 Закрытые", "manager_closed_tickets"),
+	})
+
+	// Кнопки поиска, фильтров и сортировки
+	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("🔍 Поиск", "manager_search_ticket"),
+		tgbotapi.NewInlineKeyboardButtonData("🔎 Фильтры", "filter_menu"),
+		tgbotapi.NewInlineKeyboardButtonData("↕️ Сортировка", "list_sort_menu"),
+	})
+	if filter.Search != nil {
+		keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+			tgbotapi.NewInlineKeyboardButtonData("✖️ Сбросить поиск", "filter_search_clear"),
+		})
+	}
+
+	// Кнопки тикетов текущей страницы (по 2 в ряд)
+	var row []tgbotapi.InlineKeyboardButton
+	for _, ticket := range page {
+		buttonText := fmt.Sprintf("#%d", ticket.ID)
+		if ticket.FirstName != "" {
+			shortName := []rune(ticket.FirstName)
+			if len(shortName) > 8 {
+				shortName = append(shortName[:8], []rune("...")...)
+			}
+			buttonText = fmt.Sprintf("#%d %s", ticket.ID, string(shortName))
+		}
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(buttonText, fmt.Sprintf("ticket_view_%d", ticket.ID)))
+		if len(row) == 2 {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+	}
+
+	// Навигация по страницам
+	if pages > 1 {
+		var nav []tgbotapi.InlineKeyboardButton
+		if filter.Page > 0 {
+			nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("◀️", fmt.Sprintf("list_page_%d", filter.Page-1)))
+		}
+		nav = append(nav, tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("%d/%d", filter.Page+1, pages), "list_refresh"))
+		if filter.Page < pages-1 {
+			nav = append(nav, tgbotapi.NewInlineKeyboardButtonData("▶️", fmt.Sprintf("list_page_%d", filter.Page+1)))
+		}
+		keyboard = append(keyboard, nav)
+	}
+
+	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("🔙 Назад", "back_to_manager_menu"),
+	})
+	return text.String(), tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+}
+
+// editTicketList перерисовывает список тикетов в том же сообщении
+func editTicketList(bot *tgbotapi.BotAPI, chatID int64, messageID int) {
+	text, keyboard := renderTicketList(chatID)
+	bot.Send(tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, keyboard))
+}
+
+// showTicketSortMenu показывает варианты сортировки в сообщении списка
+func showTicketSortMenu(bot *tgbotapi.BotAPI, chatID int64, messageID int) {
+	current := ticketListFilterFor(chatID).sortMode()
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for _, o := range ticketSortOptions {
+		title := o.Title
+		if o.Key == current {
+			title = "✅ " + title
+		}
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(title, "list_sort_"+o.Key),
+		))
+	}
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔙 К списку", "list_refresh"),
+	))
+	text := "↕️ Сортировка списка тикетов\n\n" +
+		"🆕 Новые — по номеру тикета\n" +
+		"🕐 Активность — по последнему сообщению\n" +
+		"⏳ Дольше ждут — открытые тикеты, где клиент дольше всех ждет ответа\n" +
+		"💬 Без ответа — сначала тикеты, где последним писал клиент"
+	bot.Send(tgbotapi.NewEditMessageTextAndMarkup(chatID, messageID, text, tgbotapi.NewInlineKeyboardMarkup(keyboard...)))
+}
+
+// handleTicketListCallback обрабатывает кнопки list_* (страницы и сортировка) с редактированием сообщения
+func handleTicketListCallback(bot *tgbotapi.BotAPI, chatID int64, messageID int, data string) {
+	filter := ticketListFilterFor(chatID)
+	switch {
+	case data == "list_sort_menu":
+		showTicketSortMenu(bot, chatID, messageID)
+		return
+	case data == "list_refresh":
+	case strings.HasPrefix(data, "list_page_"):
+		page, err := strconv.Atoi(strings.TrimPrefix(data, "list_page_"))
+		if err != nil {
+			return
+		}
+		filter.Page = page
+	case strings.HasPrefix(data, "list_sort_"):
+		filter.Sort = strings.TrimPrefix(data, "list_sort_")
+		filter.Page = 0
+	}
+	editTicketList(bot, chatID, messageID)
+}
@@ -0,0 +1,461 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ticketOption — значение справочника (категория или приоритет тикета)
+type ticketOption struct {
+	Key   string
+	Title string
+}
+
+// ticketCategories — темы обращений, которые клиент выбирает при связи с менеджером
+var ticketCategories = []ticketOption{
+	{"size", "📏 Вопрос по размеру"},
+	{"order", "📦 Статус заказа"},
+	{"return", "↩️ Возврат"},
+	{"collab", "🤝 Сотрудничество"},
+}
+
+// ticketPriorities — приоритеты тикетов по возрастанию срочности
+var ticketPriorities = []ticketOption{
+	{"low", "⬇️ Низкий"},
+	{"normal", "➖ Обычный"},
+	{"high", "⬆️ Высокий"},
+	{"urgent", "🔥 Срочный"},
+}
+
+const defaultTicketPriority = "normal"
+const maxTagLength = 20 // ограничение длины тега (callback_data кнопок фильтра — до 64 байт)
+
+var contactCategoryState = make(map[int64]string) // chatID клиента -> выбранная категория обращения
+var tagInputState = make(map[int64]int)           // chatID менеджера -> ID тикета, для которого вводятся теги
+
+// ticketListFilter — фильтры списка тикетов менеджера
+type ticketListFilter struct {
+	Status   string
+	Category string
+	Priority string
+	Tag      string
+	Assignee int64         // 0 — любой ответственный, -1 — без ответственного, иначе ID менеджера
+	Search   *ticketSearch // условия поиска, nil — поиск не задан
+	Sort     string        // ключ ticketSortOptions
+	Page     int           // текущая страница списка, с нуля
+}
+
+var ticketFilters = make(map[int64]*ticketListFilter) // chatID менеджера -> текущие фильтры
+
+func findTicketOption(options []ticketOption, key string) (ticketOption, bool) {
+	for _, o := range options {
+		if o.Key == key {
+			return o, true
+		}
+	}
+	return ticketOption{}, false
+}
+
+// categoryText возвращает название категории для отображения
+func categoryText(key string) string {
+	if o, ok := findTicketOption(ticketCategories, key); ok {
+		return o.Title
+	}
+	return "Не указана"
+}
+
+// ticketPriority возвращает приоритет тикета (по умолчанию — обычный)
+func ticketPriority(ticket *Ticket) string {
+	if ticket.Priority == "" {
+		return defaultTicketPriority
+	}
+	return ticket.Priority
+}
+
+// priorityText возвращает название приоритета для отображения
+func priorityText(key string) string {
+	if o, ok := findTicketOption(ticketPriorities, key); ok {
+		return o.Title
+	}
+	return key
+}
+
+// ticketMetaLines возвращает строки карточки о категории, приоритете и тегах
+func ticketMetaLines(ticket *Ticket) string {
+	lines := fmt.Sprintf("🗂 Категория: %s\n⚡ Приоритет: %s\n", categoryText(ticket.Category), priorityText(ticketPriority(ticket)))
+	if len(ticket.Tags) > 0 {
+		lines += fmt.Sprintf("🔖 Теги: %s\n", formatTags(ticket.Tags))
+	}
+	return lines
+}
+
+// formatTags форматирует теги в строку вида "#опт #доставка"
+func formatTags(tags []string) string {
+	parts := make([]string, 0, len(tags))
+	for _, t := range tags {
+		parts = append(parts, "#"+t)
+	}
+	return strings.Join(parts, " ")
+}
+
+// normalizeTag приводит тег к единому виду: без #, в нижнем регистре, пробелы заменены на _
+func normalizeTag(tag string) string {
+	tag = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(tag), "#")))
+	tag = strings.Join(strings.Fields(tag), "_")
+	if utf8.RuneCountInString(tag) > maxTagLength {
+		tag = string([]rune(tag)[:maxTagLength])
+	}
+	return tag
+}
+
+// handleContactCategoryCallback запоминает тему обращения и переходит к сбору имени
+func handleContactCategoryCallback(bot *tgbotapi.BotAPI, chatID int64, data string) {
+	key := strings.TrimPrefix(data, "contact_category_")
+	if _, ok := findTicketOption(ticketCategories, key); !ok {
+		showContactManagerMenu(bot, chatID)
+		return
+	}
+	contactCategoryState[chatID] = key
+
+	nameCollectState[chatID] = true
+	prompt := tgbotapi.NewMessage(chatID, "Как к вам обращаться? Укажите имя.\n\nИспользуйте /cancel для отмены.")
+	bot.Send(prompt)
+}
+
+// applyContactCategory переносит выбранную клиентом тему обращения в тикет
+func applyContactCategory(chatID int64, ticketID int) {
+	key, ok := contactCategoryState[chatID]
+	if !ok {
+		return
+	}
+	delete(contactCategoryState, chatID)
+	if ticket, exists := tickets[ticketID]; exists {
+		ticket.Category = key
+		saveTickets()
+	}
+}
+
+// showTicketOptionMenu показывает менеджеру выбор категории или приоритета тикета
+func showTicketOptionMenu(bot *tgbotapi.BotAPI, chatID int64, ticketID int, field string) {
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+
+	options, current, title := ticketCategories, ticket.Category, "🗂 Категория тикета #%d:"
+	if field == "priority" {
+		options, current, title = ticketPriorities, ticketPriority(ticket), "⚡ Приоритет тикета #%d:"
+	}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf(title, ticketID))
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for _, o := range options {
+		text := o.Title
+		if o.Key == current {
+			text = "✅ " + text
+		}
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(text, fmt.Sprintf("ticket_set_%s_%d_%s", field, ticketID, o.Key)),
+		))
+	}
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("🔙 К тикету", fmt.Sprintf("ticket_view_%d", ticketID)),
+	))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	bot.Send(msg)
+}
+
+// handleTicketSetOption обрабатывает ticket_set_<category|priority>_<ID>_<key>
+func handleTicketSetOption(bot *tgbotapi.BotAPI, chatID int64, data string) {
+	parts := strings.SplitN(strings.TrimPrefix(data, "ticket_set_"), "_", 3)
+	if len(parts) != 3 {
+		return
+	}
+	ticketID, err := strconv.Atoi(parts[1])
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Ошибка ID тикета"))
+		return
+	}
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+
+	field, key := parts[0], parts[2]
+	switch field {
+	case "category":
+		if _, ok := findTicketOption(ticketCategories, key); !ok {
+			return
+		}
+		ticket.Category = key
+		logTicketEvent(ticket, "category", chatID, fmt.Sprintf("%s изменил категорию: %s", managerDisplayName(chatID), categoryText(key)))
+	case "priority":
+		if _, ok := findTicketOption(ticketPriorities, key); !ok {
+			return
+		}
+		ticket.Priority = key
+		logTicketEvent(ticket, "priority", chatID, fmt.Sprintf("%s изменил приоритет: %s", managerDisplayName(chatID), priorityText(key)))
+	default:
+		return
+	}
+	saveTickets()
+	showTicketDetails(bot, chatID, ticketID)
+}
+
+// startTagInput переводит менеджера в режим ввода тегов для тикета
+func startTagInput(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	tagInputState[chatID] = ticketID
+
+	current := "нет"
+	if len(ticket.Tags) > 0 {
+		current = formatTags(ticket.Tags)
+	}
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🔖 Теги тикета #%d: %s\n\n"+
+		"Отправьте теги через пробел или запятую. Чтобы удалить тег, поставьте перед ним минус (например: -опт).\n\n"+
+		"Используйте /cancel для отмены", ticketID, current))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("ticket_view_%d", ticketID)),
+		),
+	)
+	bot.Send(msg)
+}
+
+// handleTagInput обрабатывает ввод тегов менеджером
+func handleTagInput(bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
+	chatID := message.Chat.ID
+	ticketID, ok := tagInputState[chatID]
+	if !ok {
+		return false
+	}
+	delete(tagInputState, chatID)
+
+	if message.Text == "/cancel" {
+		showTicketDetails(bot, chatID, ticketID)
+		return true
+	}
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return true
+	}
+
+	words := strings.FieldsFunc(message.Text, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' })
+	for _, w := range words {
+		remove := strings.HasPrefix(w, "-")
+		tag := normalizeTag(strings.TrimPrefix(w, "-"))
+		if tag == "" {
+			continue
+		}
+		if remove {
+			ticket.Tags = removeString(ticket.Tags, tag)
+		} else if !containsString(ticket.Tags, tag) {
+			ticket.Tags = append(ticket.Tags, tag)
+		}
+	}
+	saveTickets()
+
+	log.Printf("Теги тикета #%d обновлены менеджером %d: %v", ticketID, chatID, ticket.Tags)
+	showTicketDetails(bot, chatID, ticketID)
+	return true
+}
+
+func removeString(list []string, s string) []string {
+	result := list[:0]
+	for _, v := range list {
+		if v != s {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// ticketListFilterFor возвращает фильтры списка тикетов менеджера
+func ticketListFilterFor(chatID int64) *ticketListFilter {
+	f, ok := ticketFilters[chatID]
+	if !ok {
+		f = &ticketListFilter{}
+		ticketFilters[chatID] = f
+	}
+	return f
+}
+
+// matches проверяет, проходит ли тикет фильтры
+func (f *ticketListFilter) matches(ticket *Ticket) bool {
+	if f.Status != "" && ticket.Status != f.Status {
+		return false
+	}
+	if f.Category != "" && ticket.Category != f.Category {
+		return false
+	}
+	if f.Priority != "" && ticketPriority(ticket) != f.Priority {
+		return false
+	}
+	if f.Tag != "" && !containsString(ticket.Tags, f.Tag) {
+		return false
+	}
+	if f.Assignee == -1 && ticket.AssigneeID != 0 || f.Assignee > 0 && ticket.AssigneeID != f.Assignee {
+		return false
+	}
+	if f.Search != nil && !f.Search.matches(ticket) {
+		return false
+	}
+	return true
+}
+
+// describe возвращает описание дополнительных фильтров (кроме статуса)
+func (f *ticketListFilter) describe() string {
+	var parts []string
+	if f.Category != "" {
+		parts = append(parts, categoryText(f.Category))
+	}
+	if f.Priority != "" {
+		parts = append(parts, priorityText(f.Priority))
+	}
+	if f.Tag != "" {
+		parts = append(parts, "#"+f.Tag)
+	}
+	if f.Assignee == -1 {
+		parts = append(parts, "👻 без ответственного")
+	} else if f.Assignee > 0 {
+		parts = append(parts, "🙋 "+managerDisplayName(f.Assignee))
+	}
+	if f.Search != nil {
+		parts = append(parts, "🔍 «"+f.Search.Query+"»")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// allTicketTags возвращает все теги, встречающиеся в тикетах, по алфавиту
+func allTicketTags() []string {
+	seen := make(map[string]bool)
+	var tags []string
+	for _, t := range tickets {
+		for _, tag := range t.Tags {
+			if !seen[tag] {
+				seen[tag] = true
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// showTicketFilterMenu показывает менеджеру выбор фильтров по категории, приоритету и тегу
+func showTicketFilterMenu(bot *tgbotapi.BotAPI, chatID int64) {
+	f := ticketListFilterFor(chatID)
+	mark := func(selected bool, text string) string {
+		if selected {
+			return "✅ " + text
+		}
+		return text
+	}
+
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	var row []tgbotapi.InlineKeyboardButton
+	for _, c := range ticketCategories {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(mark(f.Category == c.Key, c.Title), "filter_category_"+c.Key))
+		if len(row) == 2 {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	for _, p := range ticketPriorities {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(mark(f.Priority == p.Key, p.Title), "filter_priority_"+p.Key))
+		if len(row) == 2 {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+		row = nil
+	}
+	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData(mark(f.Assignee == chatID, "🙋 Мои"), "filter_assignee_me"),
+		tgbotapi.NewInlineKeyboardButtonData(mark(f.Assignee == -1, "👻 Без ответственного"), "filter_assignee_none"),
+	})
+	tags := allTicketTags()
+	if len(tags) > 12 {
+		tags = tags[:12]
+	}
+	for _, tag := range tags {
+		row = append(row, tgbotapi.NewInlineKeyboardButtonData(mark(f.Tag == tag, "#"+tag), "filter_tag_"+tag))
+		if len(row) == 3 {
+			keyboard = append(keyboard, row)
+			row = nil
+		}
+	}
+	if len(row) > 0 {
+		keyboard = append(keyboard, row)
+	}
+	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("♻️ Сбросить", "filter_reset"),
+		tgbotapi.NewInlineKeyboardButtonData("🔙 К списку", "filter_apply"),
+	})
+
+	text := "🔎 Фильтры списка тикетов\n\nФильтры разных групп объединяются между собой и с поиском. Повторное нажатие снимает фильтр."
+	if d := f.describe(); d != "" {
+		text += "\n\nВыбрано: " + d
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	bot.Send(msg)
+}
+
+// handleTicketFilterCallback обрабатывает кнопки filter_*
+func handleTicketFilterCallback(bot *tgbotapi.BotAPI, chatID int64, data string) {
+	f := ticketListFilterFor(chatID)
+	toggle := func(current *string, value string) {
+		if *current == value {
+			*current = ""
+		} else {
+			*current = value
+		}
+	}
+
+	switch {
+	case data == "filter_menu":
+	case data == "filter_apply":
+		showTicketsWithFilters(bot, chatID, f.Status)
+		return
+	case data == "filter_reset":
+		*f = ticketListFilter{Status: f.Status, Sort: f.Sort}
+		showTicketsWithFilters(bot, chatID, f.Status)
+		return
+	case data == "filter_search_clear":
+		f.Search = nil
+		showTicketsWithFilters(bot, chatID, f.Status)
+		return
+	case strings.HasPrefix(data, "filter_category_"):
+		toggle(&f.Category, strings.TrimPrefix(data, "filter_category_"))
+	case strings.HasPrefix(data, "filter_priority_"):
+		toggle(&f.Priority, strings.TrimPrefix(data, "filter_priority_"))
+	case strings.HasPrefix(data, "filter_tag_"):
+		toggle(&f.Tag, strings.TrimPrefix(data, "filter_tag_"))
+	case data == "filter_assignee_me" || data == "filter_assignee_none":
+		value := chatID
+		if data == "filter_assignee_none" {
+			value = -1
+		}
+		if f.Assignee == value {
+			value = 0
+		}
+		f.Assignee = value
+	}
+	showTicketFilterMenu(bot, chatID)
+}
@@ -18,28 +18,74 @@ var userTickets = make(map[int64]int) // связь пользователь ->
 var nextTicketID = 1
 
 type Message struct {
-	ID            int       `json:"id"`
-	SenderID      int64     `json:"sender_id"`
-	Text          string    `json:"text"`
-	Time          time.Time `json:"time"`
-	IsFromManager bool      `json:"is_from_manager"`
+	ID            int               `json:"id"`
+	SenderID      int64             `json:"sender_id"`
+	Text          string            `json:"text"`
+	Time          time.Time         `json:"time"`
+	IsFromManager bool              `json:"is_from_manager"`
+	Attachments   []Attachment      `json:"attachments,omitempty"`
+	TgMessageID   int               `json:"tg_message_id,omitempty"` // исходное сообщение в чате отправителя
+	TgChatID      int64             `json:"tg_chat_id,omitempty"`    // чат исходного сообщения (личный чат или группа поддержки)
+	Mirrors       []MessageRef      `json:"mirrors,omitempty"`       // копии, разосланные ботом (уведомления менеджерам, ответ клиенту)
+	Revisions     []MessageRevision `json:"revisions,omitempty"`     // прежние версии текста, если сообщение редактировали
+	EditedAt      time.Time         `json:"edited_at,omitempty"`
+	RecalledAt    time.Time         `json:"recalled_at,omitempty"` // ответ менеджера отозван и удален у клиента
+	Delivery      string            `json:"delivery,omitempty"`    // доставка ответа менеджера клиенту: "delivered", "failed", "blocked"
+	DeliveryError string            `json:"delivery_error,omitempty"`
+	TemplateID    int               `json:"template_id,omitempty"` // ответ составлен из шаблона
+	Aliases       []MessageAlias    `json:"aliases,omitempty"`     // прежние номера, если сообщение перенесено из другого тикета
+}
+
+// MessageAlias — номер, под которым сообщение было в другом тикете до объединения или разделения.
+// По нему находятся сообщения из старых кнопок «Отозвать».
+type MessageAlias struct {
+	TicketID  int `json:"ticket_id"`
+	MessageID int `json:"message_id"`
 }
 
 type Ticket struct {
-	ID              int       `json:"id"`
-	UserID          int64     `json:"user_id"`
-	Username        string    `json:"username"`
-	FirstName       string    `json:"first_name"`
-	LastName        string    `json:"last_name"`
-	Height          int       `json:"height"`
-	ChestSize       int       `json:"chest_size"`
-	Oversize        bool      `json:"oversize"`
-	RecommendedSize string    `json:"recommended_size"`
-	Question        string    `json:"question"`
-	Status          string    `json:"status"` // "open", "closed"
-	CreatedAt       time.Time `json:"created_at"`
-	LastMessage     time.Time `json:"last_message"`
-	Messages        []Message `json:"messages"`
+	ID                 int            `json:"id"`
+	UserID             int64          `json:"user_id"`
+	Username           string         `json:"username"`
+	FirstName          string         `json:"first_name"`
+	LastName           string         `json:"last_name"`
+	Height             int            `json:"height"`
+	ChestSize          int            `json:"chest_size"`
+	Oversize           bool           `json:"oversize"`
+	RecommendedSize    string         `json:"recommended_size"`
+	Product            string         `json:"product,omitempty"`
+	Measurements       map[string]int `json:"measurements,omitempty"` // замеры кроме роста и обхвата груди
+	Question           string         `json:"question"`
+	Status             string         `json:"status"` // "open", "closed"
+	CreatedAt          time.Time      `json:"created_at"`
+	LastMessage        time.Time      `json:"last_message"`
+	Messages           []Message      `json:"messages"`
+	AssigneeID         int64          `json:"assignee_id,omitempty"` // менеджер, взявший тикет
+	AssigneeName       string         `json:"assignee_name,omitempty"`
+	AssignedAt         time.Time      `json:"assigned_at,omitempty"`
+	Events             []TicketEvent  `json:"events,omitempty"`   // история действий по тикету
+	Category           string         `json:"category,omitempty"` // тема обращения: ключ ticketCategories
+	Priority           string         `json:"priority,omitempty"` // ключ ticketPriorities, пусто — обычный
+	Tags               []string       `json:"tags,omitempty"`
+	SLABreaches        []SLABreach    `json:"sla_breaches,omitempty"`         // напоминания и эскалации по ожиданию клиента
+	InactivityWarnedAt time.Time      `json:"inactivity_warned_at,omitempty"` // когда клиента предупредили об автозакрытии
+	ClosedAt           time.Time      `json:"closed_at,omitempty"`
+	CloseReason        string         `json:"close_reason,omitempty"`    // "manager", "auto", "merged"
+	Notifications      []MessageRef   `json:"notifications,omitempty"`   // карточки и напоминания по тикету в чатах менеджеров
+	TopicID            int            `json:"topic_id,omitempty"`        // тема тикета в группе поддержки
+	Notes              []TicketNote   `json:"notes,omitempty"`           // внутренние заметки менеджеров
+	Rating             *TicketRating  `json:"rating,omitempty"`          // оценка клиента после закрытия
+	Undeliverable      bool           `json:"undeliverable,omitempty"`   // клиент заблокировал бота, сообщения не доставляются
+	MergedInto         int            `json:"merged_into,omitempty"`     // тикет, к которому присоединен этот
+	NextMessageID      int            `json:"next_message_id,omitempty"` // номер следующего сообщения; номера не переиспользуются
+}
+
+// TicketEvent — запись в истории тикета (назначение, закрытие и т.п.)
+type TicketEvent struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"` // "claimed", "released", "routed", "closed", "reopened", "category", "priority", "sla_remind", "sla_escalate", "inactivity_warning", "kept_alive", "rated", "recalled", "undeliverable", "delivery_restored", "transferred", "merged", "split"
+	ActorID int64     `json:"actor_id,omitempty"`
+	Text    string    `json:"text"`
 }
 
 // Функции для работы с файлом тикетов
@@ -87,114 +133,178 @@ func loadTickets() {
 	}
 	nextTicketID = maxID + 1
 
-	// Восстанавливаем userTickets
-	for id, ticket := range tickets {
-		userTickets[ticket.UserID] = id
-	}
+	// Восстанавливаем userTickets: у клиента может быть несколько тикетов
+	restoreUserTickets()
+
+	// Восстанавливаем связи сообщений Telegram с тикетами
+	rebuildTelegramMessageIndex()
+	rebuildTopicIndex()
 
 	log.Printf("Загружено %d тикетов из файла", len(tickets))
 }
 
 func createTicketAndAskQuestion(bot *tgbotapi.BotAPI, chatID int64, recommendedSize string) {
-	// Проверяем, есть ли данные пользователя для создания тикета
-	state, exists := userStates[chatID]
+	// Создаем тикет с данными подбора клиента (если есть) или без них
+	state := userStates[chatID]
+	newClientTicket(chatID, state, recommendedSize)
 
-	// Создаем тикет с данными клиента (если есть) или без них
-	var ticket *Ticket
-	now := time.Now()
+	// Просим пользователя написать вопрос
+	msg := tgbotapi.NewMessage(chatID, "✅ Создан диалог с менеджером!\n\nКакой у вас вопрос? Напишите его в этом чате, и менеджер получит ваше сообщение.")
+
+	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("Главное меню", "back_to_menu"),
+		),
+	)
 
-	if exists {
+	msg.ReplyMarkup = keyboard
+	bot.Send(msg)
+
+	// Включаем режим диалога с менеджером
+	questionStates[chatID] = true
+}
+
+// newClientTicket создает открытый тикет клиента (с данными подбора размера, если state задан),
+// делает его текущим тикетом клиента и распределяет между менеджерами
+func newClientTicket(chatID int64, state *UserState, recommendedSize string) *Ticket {
+	now := time.Now()
+	ticket := &Ticket{
+		ID:              nextTicketID,
+		UserID:          chatID,
+		Username:        "", // будет заполнено при первом сообщении
+		FirstName:       "", // будет заполнено при первом сообщении
+		LastName:        "", // будет заполнено при первом сообщении
+		RecommendedSize: recommendedSize,
+		Question:        "",
+		Status:          "open",
+		CreatedAt:       now,
+		LastMessage:     now,
+		Messages:        []Message{},
+	}
+	if state != nil {
 		// Есть данные подбора размера
-		ticket = &Ticket{
-			ID:        nextTicketID,
-			UserID:    chatID,
-			Username:  "", // будет заполнено при первом сообщении
-			FirstName: "", // будет заполнено при первом сообщении
-			LastName:  "", // будет заполнено при первом сообщении
-			Height:    state.Height,
-			ChestSize: state.ChestSize,
-			Oversize:  state.Oversize,
-			RecommendedSize: func() string {
-				if recommendedSize != "" {
-					return recommendedSize
-				}
-				mark, _ := getSizeInfo(state.ChestSize, state.Oversize)
-				return mark
-			}(),
-			Question:    "",
-			Status:      "open",
-			CreatedAt:   now,
-			LastMessage: now,
-			Messages:    []Message{},
-		}
-	} else {
-		// Нет данных подбора размера - создаем тикет без них
-		ticket = &Ticket{
-			ID:              nextTicketID,
-			UserID:          chatID,
-			Username:        "", // будет заполнено при первом сообщении
-			FirstName:       "", // будет заполнено при первом сообщении
-			LastName:        "", // будет заполнено при первом сообщении
-			Height:          0,
-			ChestSize:       0,
-			Oversize:        false,
-			RecommendedSize: recommendedSize,
-			Question:        "",
-			Status:          "open",
-			CreatedAt:       now,
-			LastMessage:     now,
-			Messages:        []Message{},
+		ticket.Height = state.Height
+		ticket.ChestSize = state.ChestSize
+		ticket.Oversize = state.Oversize
+		ticket.Product = surveyProductName(state)
+		ticket.Measurements = extraMeasurements(state)
+		switch {
+		case recommendedSize != "":
+		case state.SelectedTee == surveyAllProducts:
+			// У товаров каталога разные таблицы размеров
+			ticket.RecommendedSize = "Не определен"
+		default:
+			ticket.RecommendedSize, _ = getSizeInfo(state.ChestSize, state.Oversize)
 		}
 	}
 
-	// Сохраняем тикет
-	tickets[nextTicketID] = ticket
-	userTickets[chatID] = nextTicketID
-	nextTicketID++
+	userTickets[chatID] = ticket.ID
+	registerTicket(ticket)
+	return ticket
+}
 
-	// Сохраняем тикеты в файл
+// registerTicket добавляет новый тикет (ID = nextTicketID) в общий список, назначает его по правилам
+// маршрутизации и сохраняет тикеты
+func registerTicket(ticket *Ticket) {
+	tickets[ticket.ID] = ticket
+	nextTicketID++
+	routeTicket(ticket)
 	saveTickets()
+}
 
-	// Просим пользователя написать вопрос
-	msg := tgbotapi.NewMessage(chatID, "✅ Создан диалог с менеджером!\n\nКакой у вас вопрос? Напишите его в этом чате, и менеджер получит ваше сообщение.")
+// createSizeClarificationTicket создает тикет с замерами клиента и причиной, по которой подбор требует уточнения
+func createSizeClarificationTicket(bot *tgbotapi.BotAPI, chatID int64, user *tgbotapi.User) {
+	saved, ok := lastMeasurements[chatID]
+	if !ok {
+		bot.Send(tgbotapi.NewMessage(chatID, "Замеры не найдены. Пройдите подбор размера."))
+		startSurvey(bot, chatID)
+		return
+	}
 
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
+	productName, rec := surveyRecommendation(saved)
+	reason := rec.Reason
+	if reason == "" {
+		reason = "Клиент просит уточнить размер"
+	}
+	question := fmt.Sprintf("Уточнение размера (%s): %s", productName, reason)
+
+	ticket := newClientTicket(chatID, saved, rec.Mark)
+	ticket.Oversize = saved.Oversize && surveyAllowsOversize(saved)
+	ticket.Product = productName
+	ticket.Question = question
+	ticket.Category = "size"
+	updateTicketUserInfo(ticket.ID, user.UserName, user.FirstName, user.LastName)
+
+	// Вопрос сформирован ботом: в тикете он хранится без исходного сообщения Telegram,
+	// клиенту показываем его копию для сведения
+	sendToClient(bot, ticket, tgbotapi.NewMessage(chatID, "💬 Ваш запрос менеджеру:\n\n"+question), 0)
+	postClientMessage(bot, ticket, nil, question, nil)
+
+	// Дальнейшие сообщения клиента попадут в этот тикет
+	questionStates[chatID] = true
+
+	text := fmt.Sprintf("✅ Создан тикет #%d для уточнения размера.\n\nМенеджер получил ваши замеры и причину уточнения. Если хотите, допишите комментарий в этом чате.", ticket.ID)
+	if !isWithinBusinessHours() {
+		text += "\n\n⏰ Менеджер отвечает с 09:00 до 20:00. Он свяжется с вами в это время."
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
 		tgbotapi.NewInlineKeyboardRow(
 			tgbotapi.NewInlineKeyboardButtonData("Главное меню", "back_to_menu"),
 		),
 	)
-
-	msg.ReplyMarkup = keyboard
 	bot.Send(msg)
 
-	// Включаем режим диалога с менеджером
-	questionStates[chatID] = true
+	log.Printf("Создан тикет #%d для уточнения размера (чат %d): %s", ticket.ID, chatID, reason)
 }
 
 // Функции для работы с сообщениями в тикетах
 
-// addMessageToTicket добавляет сообщение в тикет
-func addMessageToTicket(ticketID int, senderID int64, text string, isFromManager bool) {
+// addMessageToTicket добавляет сообщение (и его вложения, если есть) в тикет
+// Возвращает номер сообщения в тикете (0, если тикет не найден).
+func addMessageToTicket(ticketID int, senderID int64, text string, isFromManager bool, attachments ...Attachment) int {
 	ticket, exists := tickets[ticketID]
 	if !exists {
 		log.Printf("Тикет #%d не найден", ticketID)
-		return
+		return 0
 	}
 
-	messageID := len(ticket.Messages) + 1
+	messageID := allocMessageID(ticket)
 	message := Message{
 		ID:            messageID,
 		SenderID:      senderID,
 		Text:          text,
 		Time:          time.Now(),
 		IsFromManager: isFromManager,
+		Attachments:   attachments,
 	}
 
 	ticket.Messages = append(ticket.Messages, message)
 	ticket.LastMessage = time.Now()
+	if !isFromManager {
+		clearUndeliverable(ticket)
+	}
 
 	saveTickets()
 	log.Printf("Сообщение добавлено в тикет #%d", ticketID)
+	return messageID
+}
+
+// allocMessageID выдает номер для нового сообщения тикета. Номера не переиспользуются даже после
+// переноса сообщений в другой тикет, чтобы старые кнопки и ссылки не указывали на чужие сообщения.
+func allocMessageID(ticket *Ticket) int {
+	if ticket.NextMessageID == 0 {
+		// Тикеты, сохраненные до появления счетчика
+		ticket.NextMessageID = 1
+		for _, m := range ticket.Messages {
+			if m.ID >= ticket.NextMessageID {
+				ticket.NextMessageID = m.ID + 1
+			}
+		}
+	}
+	id := ticket.NextMessageID
+	ticket.NextMessageID++
+	return id
 }
 
 // updateTicketUserInfo обновляет информацию о пользователе в тикете
@@ -236,7 +346,7 @@ func getTicketMessages(ticketID int) string {
 		result.WriteString(fmt.Sprintf("%s (%s):\n%s\n\n",
 			senderType,
 			msg.Time.Format("02.01.2006 15:04:05"),
-			msg.Text))
+			messageDisplayText(msg)))
 	}
 
 	return result.String()
@@ -248,6 +358,11 @@ func sendClientCardToManager(bot *tgbotapi.BotAPI, ticket *Ticket) {
 		oversizeText = "Да"
 	}
 
+	extraLines := ticketExtraLines(ticket)
+	if ticket.AssigneeID != 0 {
+		extraLines += fmt.Sprintf("🙋 Ответственный: %s\n", assigneeText(ticket))
+	}
+
 	// Формируем сообщение в зависимости от наличия данных
 	var messageText string
 	if ticket.Height > 0 && ticket.ChestSize > 0 {
@@ -259,6 +374,7 @@ func sendClientCardToManager(bot *tgbotapi.BotAPI, ticket *Ticket) {
 			"📐 Обхват груди: %d см\n"+
 			"👕 Оверсайз: %s\n"+
 			"✅ Рекомендуемый размер: %s\n"+
+			"%s"+
 			"🕐 Создан: %s\n\n"+
 			"💬 Ответьте клиенту текстом или используйте кнопки для управления тикетом",
 			ticket.ID,
@@ -270,6 +386,7 @@ func sendClientCardToManager(bot *tgbotapi.BotAPI, ticket *Ticket) {
 			ticket.ChestSize,
 			oversizeText,
 			ticket.RecommendedSize,
+			extraLines,
 			ticket.CreatedAt.Format("15:04 02.01.2006"))
 	} else {
 		// Нет данных подбора размера
@@ -280,6 +397,7 @@ func sendClientCardToManager(bot *tgbotapi.BotAPI, ticket *Ticket) {
 			"📐 Обхват груди: Не указан\n"+
 			"👕 Оверсайз: Не указан\n"+
 			"✅ Рекомендуемый размер: %s\n"+
+			"%s"+
 			"🕐 Создан: %s\n\n"+
 			"💬 Ответьте клиенту текстом или используйте кнопки для управления тикетом",
 			ticket.ID,
@@ -288,26 +406,89 @@ func sendClientCardToManager(bot *tgbotapi.BotAPI, ticket *Ticket) {
 			ticket.Username,
 			ticket.UserID,
 			ticket.RecommendedSize,
+			extraLines,
 			ticket.CreatedAt.Format("15:04 02.01.2006"))
 	}
 
-	// Рассылаем всем менеджерам
-	ids := getManagerIDs()
+	// В группе поддержки карточка публикуется в теме тикета
+	if supportGroupEnabled() {
+		postToTicketTopic(bot, ticket, messageText)
+	}
+
+	// Карточку получает ответственный (при автоматическом назначении) или все менеджеры
+	ids, _ := ticketNotifyRecipients(ticket)
 	if len(ids) == 0 {
-		log.Printf("Менеджеры не заданы, уведомление не отправлено")
+		if !supportGroupEnabled() {
+			log.Printf("Менеджеры не заданы, уведомление не отправлено")
+		}
 		return
 	}
 	for _, mid := range ids {
 		msg := tgbotapi.NewMessage(mid, messageText)
-		bot.Send(msg)
+		msg.ReplyMarkup = ticketCardKeyboard(ticket)
+		if sent, err := bot.Send(msg); err == nil {
+			linkTicketNotification(ticket, sent)
+		}
 	}
+	saveTickets()
 
 	log.Printf("Отправлена карточка клиента для тикета #%d менеджеру", ticket.ID)
 }
 
+// ticketExtraLines возвращает строки карточки о товаре, дополнительных замерах, вопросе (если есть),
+// а также категории, приоритете и тегах
+func ticketExtraLines(ticket *Ticket) string {
+	lines := ticketMetaLines(ticket)
+	if ticket.Product != "" {
+		lines += fmt.Sprintf("🛍 Товар: %s\n", ticket.Product)
+	}
+	if len(ticket.Measurements) > 0 {
+		lines += fmt.Sprintf("📏 Замеры: %s\n", formatMeasurements(ticket.Measurements))
+	}
+	if ticket.Question != "" {
+		lines += fmt.Sprintf("❓ Вопрос: %s\n", ticket.Question)
+	}
+	return lines
+}
+
+// logTicketEvent добавляет запись в историю тикета (сохранение — на вызывающей стороне)
+func logTicketEvent(ticket *Ticket, kind string, actorID int64, text string) {
+	ticket.Events = append(ticket.Events, TicketEvent{
+		Time:    time.Now(),
+		Kind:    kind,
+		ActorID: actorID,
+		Text:    text,
+	})
+}
+
+// showTicketHistory показывает менеджеру историю действий по тикету
+func showTicketHistory(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		msg := tgbotapi.NewMessage(chatID, "❌ Тикет не найден")
+		bot.Send(msg)
+		return
+	}
+
+	text := fmt.Sprintf("🕓 История тикета #%d\n\n", ticketID)
+	text += fmt.Sprintf("%s — тикет создан\n", ticket.CreatedAt.Format("02.01 15:04"))
+	for _, e := range ticket.Events {
+		text += fmt.Sprintf("%s — %s\n", e.Time.Format("02.01 15:04"), e.Text)
+	}
+
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("🔙 К тикету", fmt.Sprintf("ticket_view_%d", ticketID)),
+		),
+	)
+	bot.Send(msg)
+}
+
 func handleManagerQuestion(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	chatID := message.Chat.ID
-	question := message.Text
+	question := messageContent(message)
+	attachments := messageAttachments(message)
 
 	// Находим тикет пользователя
 	ticketID, exists := userTickets[chatID]
@@ -329,31 +510,41 @@ func handleManagerQuestion(bot *tgbotapi.BotAPI, message *tgbotapi.Message) {
 	// Обновляем данные пользователя в тикете
 	updateTicketUserInfo(ticketID, message.From.UserName, message.From.FirstName, message.From.LastName)
 
-	// Добавляем сообщение клиента в тикет
-	addMessageToTicket(ticketID, chatID, question, false)
+	if !hasTicketContent(message) {
+		bot.Send(tgbotapi.NewMessage(chatID, unsupportedContentText))
+		return
+	}
+
+	postClientMessage(bot, ticket, message, question, attachments)
+
+	log.Printf("Отправлено сообщение от пользователя %d в тикет #%d", chatID, ticketID)
+}
+
+// postClientMessage добавляет сообщение клиента в тикет, связывает его с исходным сообщением Telegram
+// и рассылает менеджерам (при первом сообщении — вместе с карточкой тикета)
+func postClientMessage(bot *tgbotapi.BotAPI, ticket *Ticket, origin *tgbotapi.Message, text string, attachments []Attachment) {
+	messageID := addMessageToTicket(ticket.ID, ticket.UserID, text, false, attachments...)
+	linkMessageOrigin(ticket, messageID, origin)
 
 	// При первом сообщении формируем карточку и отправляем менеджерам
-	if t, ok := tickets[ticketID]; ok {
-		if len(t.Messages) == 1 { // только что добавленное — это первое
-			sendClientCardToManager(bot, t)
-		}
+	if len(ticket.Messages) == 1 {
+		sendClientCardToManager(bot, ticket)
 	}
 
 	// Отправляем сообщение менеджеру
-	messageText := fmt.Sprintf("💬 Новое сообщение от клиента (тикет #%d):\n\n%s", ticketID, question)
+	messageText := fmt.Sprintf("💬 Новое сообщение от клиента (тикет #%d):\n\n%s", ticket.ID,
+		messageDisplayText(Message{Text: text, Attachments: attachments}))
 
-	// Рассылаем всем менеджерам
-	ids := getManagerIDs()
-	if len(ids) == 0 {
+	// Рассылаем ответственному менеджеру или всем, если тикет не взят
+	ids, fallback := ticketNotifyRecipients(ticket)
+	if fallback {
+		messageText = unansweredPrefix(ticket) + messageText
+	}
+	if len(ids) == 0 && !supportGroupEnabled() {
 		log.Printf("Менеджеры не заданы, уведомление не отправлено")
 		return
 	}
-	for _, mid := range ids {
-		msg := tgbotapi.NewMessage(mid, messageText)
-		bot.Send(msg)
-	}
-
-	log.Printf("Отправлено сообщение от пользователя %d в тикет #%d", chatID, ticketID)
+	forwardClientMessage(bot, ticket, messageID, ids, messageText, attachments)
 }
 
 // showManagerTicketDialog показывает полный диалог тикета менеджеру
@@ -371,8 +562,8 @@ func showManagerTicketDialog(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
 		return
 	}
 
-	// Получаем полный диалог
-	dialogText := getTicketMessages(ticketID)
+	// Получаем полный диалог вместе с заметками менеджеров
+	dialogText := getTicketDialogForManager(ticketID)
 
 	// Разбиваем на части если слишком длинное
 	const maxMessageLength = 4000
@@ -446,6 +637,9 @@ func showTicketDetails(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
 	status := "🟢 Открыт"
 	if ticket.Status == "closed" {
 		status = "🔴 Закрыт"
+		if ticket.CloseReason == "auto" {
+			status += " (автоматически)"
+		}
 	}
 
 	var text string
@@ -483,6 +677,16 @@ func showTicketDetails(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
 			ticket.RecommendedSize,
 			ticket.CreatedAt.Format("15:04 02.01.2006"))
 	}
+	text += ticketExtraLines(ticket)
+	text += fmt.Sprintf("🙋 Ответственный: %s\n", assigneeText(ticket))
+	text += ticketRatingLine(ticket)
+	text += undeliverableLine(ticket)
+	text += mergedLine(ticket)
+	session, sticky := managerSessions[chatID]
+	sticky = sticky && session.Sticky && session.TicketID == ticketID
+	if sticky {
+		text += "📌 Диалог закреплен: ваши сообщения уходят клиенту\n"
+	}
 
 	// Добавляем последние сообщения
 	if len(ticket.Messages) > 0 {
@@ -496,11 +700,12 @@ func showTicketDetails(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
 			text += fmt.Sprintf("%s (%s):\n%s\n\n",
 				senderType,
 				msg.Time.Format("02.01 15:04"),
-				msg.Text)
+				messageDisplayText(msg))
 		}
 	} else {
 		text += "\n💬 Сообщений пока нет"
 	}
+	text += ticketNotesText(ticket, 3)
 
 	msg := tgbotapi.NewMessage(chatID, text)
 
@@ -514,41 +719,78 @@ func showTicketDetails(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
 		})
 	}
 
+	if n := countTicketAttachments(ticket); n > 0 {
+		keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("📎 Вложения (%d)", n), fmt.Sprintf("ticket_files_%d", ticketID)),
+		})
+	}
+
 	if ticket.Status == "open" {
 		// Для открытых тикетов: ответить и закрыть
 		keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
 			tgbotapi.NewInlineKeyboardButtonData("💬 Ответить", fmt.Sprintf("ticket_reply_%d", ticketID)),
+			stickyButton(ticketID, sticky),
+		})
+		keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+			tgbotapi.NewInlineKeyboardButtonData("📝 Шаблоны", fmt.Sprintf("ticket_templates_%d", ticketID)),
 			tgbotapi.NewInlineKeyboardButtonData("🔒 Закрыть", fmt.Sprintf("ticket_close_%d", ticketID)),
 		})
+		var assignRow []tgbotapi.InlineKeyboardButton
+		if ticket.AssigneeID == 0 {
+			assignRow = append(assignRow, tgbotapi.NewInlineKeyboardButtonData("🙋 Взять", fmt.Sprintf("ticket_claim_%d", ticketID)))
+		} else if ticket.AssigneeID == chatID {
+			assignRow = append(assignRow, tgbotapi.NewInlineKeyboardButtonData("↩️ Отказаться", fmt.Sprintf("ticket_release_%d", ticketID)))
+		}
+		assignRow = append(assignRow, tgbotapi.NewInlineKeyboardButtonData("↪️ Передать", fmt.Sprintf("ticket_transfer_%d", ticketID)))
+		keyboard = append(keyboard, assignRow)
+	} else if ticket.MergedInto != 0 {
+		// Присоединенный тикет: переписка продолжается в другом
+		keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+			tgbotapi.NewInlineKeyboardButtonData(fmt.Sprintf("🔗 К тикету #%d", ticket.MergedInto), fmt.Sprintf("ticket_view_%d", ticket.MergedInto)),
+		})
 	} else {
 		// Для закрытых тикетов: открыть
 		keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
 			tgbotapi.NewInlineKeyboardButtonData("🔓 Открыть", fmt.Sprintf("ticket_open_%d", ticketID)),
 		})
 	}
+	if ticket.MergedInto == 0 {
+		var mergeRow []tgbotapi.InlineKeyboardButton
+		if len(mergeCandidates(ticket)) > 0 {
+			mergeRow = append(mergeRow, tgbotapi.NewInlineKeyboardButtonData("🔗 Объединить", fmt.Sprintf("ticket_merge_%d", ticketID)))
+		}
+		if len(ticket.Messages) > 1 {
+			mergeRow = append(mergeRow, tgbotapi.NewInlineKeyboardButtonData("✂️ Разделить", fmt.Sprintf("ticket_split_%d", ticketID)))
+		}
+		if len(mergeRow) > 0 {
+			keyboard = append(keyboard, mergeRow)
+		}
+	}
 
-	// Кнопка "Назад"
 	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
-		tgbotapi.NewInlineKeyboardButtonData("🔙 Назад к списку", "manager_tickets"),
+		tgbotapi.NewInlineKeyboardButtonData("🗂 Категория", fmt.Sprintf("ticket_category_%d", ticketID)),
+		tgbotapi.NewInlineKeyboardButtonData("⚡ Приоритет", fmt.Sprintf("ticket_priority_%d", ticketID)),
+		tgbotapi.NewInlineKeyboardButtonData("🔖 Теги", fmt.Sprintf("ticket_tags_%d", ticketID)),
 	})
 
-	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
-	bot.Send(msg)
-}
-
-func startTicketReply(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
-	// Сохраняем ID тикета для ответа
-	userTickets[chatID] = ticketID
+	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("👤 Профиль клиента", fmt.Sprintf("profile_%d", ticket.UserID)),
+	})
 
-	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("💬 Ответ в тикет #%d\n\nНапишите ваш ответ клиенту:", ticketID))
+	historyRow := []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("🗒 Заметка", fmt.Sprintf("ticket_note_%d", ticketID)),
+	}
+	if len(ticket.Events) > 0 {
+		historyRow = append(historyRow, tgbotapi.NewInlineKeyboardButtonData("🕓 История", fmt.Sprintf("ticket_history_%d", ticketID)))
+	}
+	keyboard = append(keyboard, historyRow)
 
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("ticket_view_%d", ticketID)),
-		),
-	)
+	// Кнопка "Назад"
+	keyboard = append(keyboard, []tgbotapi.InlineKeyboardButton{
+		tgbotapi.NewInlineKeyboardButtonData("🔙 Назад к списку", "manager_tickets"),
+	})
 
-	msg.ReplyMarkup = keyboard
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
 	bot.Send(msg)
 }
 
@@ -566,24 +808,73 @@ func closeTicketFromButton(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
 		return
 	}
 
+	closeTicket(bot, ticket, chatID, "manager")
+
+	// Подтверждаем менеджеру
+	confirmMsg := tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Тикет #%d закрыт", ticketID))
+	bot.Send(confirmMsg)
+
+	log.Printf("Тикет #%d закрыт менеджером через кнопку", ticketID)
+}
+
+// closeTicket закрывает тикет и уведомляет клиента. reason: "manager" — закрыт менеджером actorID,
+// "auto" — закрыт автоматически из-за неактивности
+func closeTicket(bot *tgbotapi.BotAPI, ticket *Ticket, actorID int64, reason string) {
+	sendOutbox(bot, closeTicketOutbox(ticket, actorID, reason))
+}
+
+// closeTicketOutbox закрывает тикет и возвращает уведомления клиенту, менеджерам и в тему тикета
+func closeTicketOutbox(ticket *Ticket, actorID int64, reason string) []outMessage {
 	// Закрываем тикет
 	ticket.Status = "closed"
+	ticket.ClosedAt = time.Now()
+	ticket.CloseReason = reason
+	ticket.InactivityWarnedAt = time.Time{}
+	if reason == "auto" {
+		logTicketEvent(ticket, "closed", 0, "Тикет закрыт автоматически из-за неактивности")
+	} else {
+		logTicketEvent(ticket, "closed", actorID, fmt.Sprintf("%s закрыл тикет", managerDisplayName(actorID)))
+	}
 
 	// Сохраняем изменения в файл
 	saveTickets()
 
 	// Уведомляем клиента
-	closeMsg := tgbotapi.NewMessage(ticket.UserID, "🔒 Диалог с менеджером завершен.\n\nСпасибо за обращение! Если у вас есть другие вопросы, создайте новый диалог.")
-	bot.Send(closeMsg)
+	closeText := fmt.Sprintf("🔒 Диалог по тикету #%d завершен.\n\nСпасибо за обращение! Если у вас есть другие вопросы, создайте новый диалог.", ticket.ID)
+	if reason == "auto" {
+		closeText = fmt.Sprintf("🔒 Диалог по тикету #%d закрыт автоматически: в нем давно не было сообщений.\n\nЕсли у вас есть другие вопросы, создайте новый диалог.", ticket.ID)
+	}
+	closeMsg := tgbotapi.NewMessage(ticket.UserID, closeText)
+	// После закрытия менеджером просим клиента оценить работу
+	if reason == "manager" && ticket.Rating == nil {
+		closeMsg.Text += "\n\nОцените, пожалуйста, как мы помогли вам:"
+		closeMsg.ReplyMarkup = ratingKeyboard(ticket.ID)
+	}
+	out := []outMessage{{Msg: closeMsg, Ticket: ticket, ToClient: true}}
 
-	// Удаляем состояние вопроса
-	delete(questionStates, ticket.UserID)
+	// Удаляем состояние вопроса и переключаем клиента на другой открытый тикет, если он есть
+	if userTickets[ticket.UserID] == ticket.ID {
+		delete(questionStates, ticket.UserID)
+		reselectClientTicket(ticket)
+	}
 
-	// Подтверждаем менеджеру
-	confirmMsg := tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Тикет #%d закрыт", ticketID))
-	bot.Send(confirmMsg)
+	// Об автоматическом закрытии сообщаем тем же менеджерам, что получают сообщения клиента,
+	// и в тему тикета (до ее закрытия)
+	if reason == "auto" {
+		autoText := fmt.Sprintf("🗄 Тикет #%d закрыт автоматически из-за неактивности", ticket.ID)
+		ids, _ := ticketNotifyRecipients(ticket)
+		for _, mid := range ids {
+			out = append(out, outMessage{Msg: tgbotapi.NewMessage(mid, autoText), Ticket: ticket})
+		}
+		if ticket.TopicID != 0 {
+			out = append(out, outMessage{TopicID: ticket.TopicID, Text: autoText, Ticket: ticket})
+		}
+	}
 
-	log.Printf("Тикет #%d закрыт менеджером через кнопку", ticketID)
+	// Закрываем тему тикета в группе поддержки и завершаем сессии менеджеров в нем
+	out = append(out, topicClosedOutbox(ticket, true)...)
+	out = append(out, endManagerSessionsForTicket(ticket.ID)...)
+	return out
 }
 
 func openTicketFromButton(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
@@ -599,20 +890,32 @@ func openTicketFromButton(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
 		bot.Send(msg)
 		return
 	}
+	if ticket.MergedInto != 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("❌ Тикет присоединен к тикету #%d — откройте его", ticket.MergedInto)))
+		return
+	}
 
 	// Открываем тикет
 	ticket.Status = "open"
+	ticket.ClosedAt = time.Time{}
+	ticket.CloseReason = ""
+	ticket.LastMessage = time.Now()
+	logTicketEvent(ticket, "reopened", chatID, fmt.Sprintf("%s открыл тикет", managerDisplayName(chatID)))
 
 	// Сохраняем изменения в файл
 	saveTickets()
 
 	// Уведомляем клиента
-	openMsg := tgbotapi.NewMessage(ticket.UserID, "🔓 Диалог с менеджером возобновлен.\n\nВы можете продолжить общение в этом чате.")
-	bot.Send(openMsg)
+	openMsg := tgbotapi.NewMessage(ticket.UserID, fmt.Sprintf("🔓 Диалог по тикету #%d возобновлен.\n\nВы можете продолжить общение в этом чате.", ticket.ID))
+	sendToClient(bot, ticket, openMsg, 0)
 
-	// Включаем режим диалога для клиента
+	// Включаем режим диалога для клиента в возобновленном тикете
+	userTickets[ticket.UserID] = ticket.ID
 	questionStates[ticket.UserID] = true
 
+	// Открываем тему тикета в группе поддержки
+	setTicketTopicClosed(bot, ticket, false)
+
 	// Подтверждаем менеджеру
 	confirmMsg := tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Тикет #%d открыт", ticketID))
 	bot.Send(confirmMsg)
@@ -622,105 +925,202 @@ func openTicketFromButton(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
 
 // showTicketsWithButtons — legacy (заменено на showTicketsWithFilters). Удалено.
 
+// parseTicketIDSuffix извлекает ID тикета из callback-данных вида <prefix><ID>
+func parseTicketIDSuffix(prefix, data string) (int, bool) {
+	id, err := strconv.Atoi(strings.TrimPrefix(data, prefix))
+	return id, err == nil
+}
+
+// ticketIDCallbacks — кнопки карточки тикета вида <префикс><ID тикета>
+var ticketIDCallbacks = []struct {
+	Prefix string
+	Handle func(bot *tgbotapi.BotAPI, chatID int64, ticketID int)
+}{
+	{"ticket_view_", viewTicketFromButton},
+	{"ticket_reply_", func(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+		startManagerSession(bot, chatID, ticketID, false)
+	}},
+	{"ticket_sticky_", func(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+		startManagerSession(bot, chatID, ticketID, true)
+	}},
+	{"ticket_note_", startNoteInput},
+	{"ticket_templates_", showTicketTemplates},
+	{"ticket_close_", closeTicketFromButton},
+	{"ticket_open_", openTicketFromButton},
+	{"ticket_dialog_", showManagerTicketDialog},
+	{"ticket_claim_", claimTicket},
+	{"ticket_release_", releaseTicket},
+	{"ticket_history_", showTicketHistory},
+	{"ticket_category_", func(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+		showTicketOptionMenu(bot, chatID, ticketID, "category")
+	}},
+	{"ticket_priority_", func(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+		showTicketOptionMenu(bot, chatID, ticketID, "priority")
+	}},
+	{"ticket_tags_", startTagInput},
+	{"ticket_files_", showTicketAttachments},
+	{"ticket_split_", startSplitInput},
+}
+
 func handleTicketButtonCallback(bot *tgbotapi.BotAPI, chatID int64, callbackData string) {
-	// Обрабатываем кнопки тикетов
-	if strings.HasPrefix(callbackData, "ticket_view_") {
-		ticketIDStr := strings.TrimPrefix(callbackData, "ticket_view_")
-		ticketID, err := strconv.Atoi(ticketIDStr)
-		if err != nil {
-			msg := tgbotapi.NewMessage(chatID, "❌ Ошибка ID тикета")
-			bot.Send(msg)
-			return
-		}
-		showTicketDetails(bot, chatID, ticketID)
-	} else if strings.HasPrefix(callbackData, "ticket_reply_") {
-		ticketIDStr := strings.TrimPrefix(callbackData, "ticket_reply_")
-		ticketID, err := strconv.Atoi(ticketIDStr)
-		if err != nil {
-			msg := tgbotapi.NewMessage(chatID, "❌ Ошибка ID тикета")
-			bot.Send(msg)
-			return
-		}
-		startTicketReply(bot, chatID, ticketID)
-	} else if strings.HasPrefix(callbackData, "ticket_close_") {
-		ticketIDStr := strings.TrimPrefix(callbackData, "ticket_close_")
-		ticketID, err := strconv.Atoi(ticketIDStr)
-		if err != nil {
-			msg := tgbotapi.NewMessage(chatID, "❌ Ошибка ID тикета")
-			bot.Send(msg)
-			return
-		}
-		closeTicketFromButton(bot, chatID, ticketID)
-	} else if strings.HasPrefix(callbackData, "ticket_open_") {
-		ticketIDStr := strings.TrimPrefix(callbackData, "ticket_open_")
-		ticketID, err := strconv.Atoi(ticketIDStr)
-		if err != nil {
-			msg := tgbotapi.NewMessage(chatID, "❌ Ошибка ID тикета")
-			bot.Send(msg)
-			return
+	// Кнопки с составными данными разбирают свои обработчики
+	switch {
+	case strings.HasPrefix(callbackData, "ticket_set_"):
+		handleTicketSetOption(bot, chatID, callbackData)
+		return
+	case strings.HasPrefix(callbackData, "ticket_transfer_"):
+		handleTicketTransferCallback(bot, chatID, callbackData)
+		return
+	case strings.HasPrefix(callbackData, "ticket_merge_"):
+		handleTicketMergeCallback(bot, chatID, callbackData)
+		return
+	}
+
+	for _, c := range ticketIDCallbacks {
+		if !strings.HasPrefix(callbackData, c.Prefix) {
+			continue
 		}
-		openTicketFromButton(bot, chatID, ticketID)
-	} else if strings.HasPrefix(callbackData, "ticket_dialog_") {
-		ticketIDStr := strings.TrimPrefix(callbackData, "ticket_dialog_")
-		ticketID, err := strconv.Atoi(ticketIDStr)
-		if err != nil {
-			msg := tgbotapi.NewMessage(chatID, "❌ Ошибка ID тикета")
-			bot.Send(msg)
+		ticketID, ok := parseTicketIDSuffix(c.Prefix, callbackData)
+		if !ok {
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Ошибка ID тикета"))
 			return
 		}
-		showManagerTicketDialog(bot, chatID, ticketID)
+		c.Handle(bot, chatID, ticketID)
+		return
+	}
+}
+
+// viewTicketFromButton показывает карточку тикета, отменяя незавершенный ввод по тикетам
+func viewTicketFromButton(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+	delete(tagInputState, chatID)
+	delete(noteInputState, chatID)
+	delete(transferState, chatID)
+	delete(splitInputState, chatID)
+	delete(templateDraftState, chatID)
+	// Возврат к карточке отменяет ответ одним сообщением
+	if session, ok := managerSessions[chatID]; ok && !session.Sticky {
+		endManagerSession(chatID)
 	}
+	showTicketDetails(bot, chatID, ticketID)
 }
 
 func showContactManagerMenu(bot *tgbotapi.BotAPI, chatID int64) {
-	msg := tgbotapi.NewMessage(chatID, "Выберите способ связи с менеджером:")
+	msg := tgbotapi.NewMessage(chatID, "Выберите тему обращения к менеджеру:")
 
-	keyboard := tgbotapi.NewInlineKeyboardMarkup(
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("Написать менеджеру", "contact_manager_direct"),
-		),
-		tgbotapi.NewInlineKeyboardRow(
-			tgbotapi.NewInlineKeyboardButtonData("Назад в меню", "back_to_menu"),
-		),
-	)
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for _, c := range ticketCategories {
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(c.Title, "contact_category_"+c.Key),
+		))
+	}
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("Назад в меню", "back_to_menu"),
+	))
 
-	msg.ReplyMarkup = keyboard
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
 	bot.Send(msg)
 }
 
 // contactManagerDirect больше не используется (сбор имени перенесен в main.go)
 
-func handleManagerReplyToTicket(bot *tgbotapi.BotAPI, message *tgbotapi.Message, ticketID int) {
-	ticket, exists := tickets[ticketID]
-	if !exists {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Тикет не найден")
-		bot.Send(msg)
-		delete(userTickets, message.Chat.ID)
-		return
+// sendManagerReply сохраняет ответ менеджера в тикет и отправляет его клиенту, цитируя сообщение тикета
+// quoteMessageID (0 — последнее сообщение клиента)
+func sendManagerReply(bot *tgbotapi.BotAPI, message *tgbotapi.Message, ticket *Ticket, quoteMessageID int) bool {
+	fromGroup := isSupportGroupChat(message.Chat.ID)
+	// Служебные ответы менеджеру: в теме тикета или в личном чате
+	notify := func(text string) {
+		if fromGroup {
+			sendTopicMessage(bot, ticket, text)
+		} else {
+			bot.Send(tgbotapi.NewMessage(message.Chat.ID, text))
+		}
 	}
 
 	if ticket.Status != "open" {
-		msg := tgbotapi.NewMessage(message.Chat.ID, "❌ Тикет закрыт")
-		bot.Send(msg)
-		delete(userTickets, message.Chat.ID)
-		return
+		notify(fmt.Sprintf("❌ Тикет #%d закрыт", ticket.ID))
+		return false
 	}
 
-	replyText := message.Text
+	replyText := messageContent(message)
+	attachments := messageAttachments(message)
+	if !hasTicketContent(message) {
+		notify(unsupportedContentText)
+		return false
+	}
+
+	// Автор ответа — менеджер, а не чат: в группе поддержки Chat.ID принадлежит группе
+	senderID := message.Chat.ID
+	if message.From != nil {
+		senderID = message.From.ID
+	}
 
 	// Добавляем сообщение менеджера в тикет
-	addMessageToTicket(ticketID, message.Chat.ID, replyText, true)
+	messageID := addMessageToTicket(ticket.ID, senderID, replyText, true, attachments...)
+	linkMessageOrigin(ticket, messageID, message)
+	quoteID := quoteTargetForClient(ticket, quoteMessageID)
+
+	// Отправляем ответ клиенту, запоминая первую ошибку доставки
+	var sendErr error
+	deliver := func(c tgbotapi.Chattable) {
+		sent, err := sendToClient(bot, ticket, c, message.Chat.ID)
+		if err != nil {
+			if sendErr == nil {
+				sendErr = err
+			}
+			return
+		}
+		linkMessageMirror(ticket, messageID, sent)
+	}
+	if replyText != "" {
+		responseMsg := tgbotapi.NewMessage(ticket.UserID, fmt.Sprintf("💬 Ответ от менеджера:\n\n%s", replyText))
+		responseMsg.ReplyToMessageID = quoteID
+		responseMsg.AllowSendingWithoutReply = true
+		deliver(responseMsg)
+	}
+	for _, a := range attachments {
+		deliver(attachmentChattable(ticket.UserID, a, "💬 От менеджера"))
+	}
+	setMessageDelivery(ticket, messageID, sendErr)
 
-	// Отправляем ответ клиенту
-	responseMsg := tgbotapi.NewMessage(ticket.UserID, fmt.Sprintf("💬 Ответ от менеджера:\n\n%s", replyText))
-	bot.Send(responseMsg)
+	// Ответ из личного чата дублируем в тему, чтобы в ней была вся переписка
+	if !fromGroup && supportGroupEnabled() {
+		mirrorManagerReplyToTopic(bot, ticket, messageID, message.Chat.ID)
+	}
+	saveTickets()
 
-	// Удаляем состояние ответа
-	delete(userTickets, message.Chat.ID)
+	if sendErr != nil {
+		notify(fmt.Sprintf("⚠️ Ответ сохранен в тикете #%d, но не доставлен клиенту: %s", ticket.ID, deliveryErrorText(sendErr)))
+		log.Printf("Ответ менеджера в тикет #%d не доставлен: %v", ticket.ID, sendErr)
+		return true
+	}
 
-	// Подтверждаем менеджеру
-	confirmMsg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("✅ Ответ отправлен в тикет #%d", ticketID))
-	bot.Send(confirmMsg)
+	// Подтверждаем менеджеру (в группе ответ и так виден в теме, отозвать его можно командой /recall)
+	if !fromGroup {
+		confirmMsg := tgbotapi.NewMessage(message.Chat.ID, fmt.Sprintf("✅ Ответ отправлен в тикет #%d", ticket.ID))
+		confirmMsg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+			tgbotapi.NewInlineKeyboardRow(recallButton(ticket.ID, messageID)),
+		)
+		bot.Send(confirmMsg)
+	}
+
+	log.Printf("Менеджер %d ответил в тикет #%d: %s %s", senderID, ticket.ID, replyText, attachmentsText(attachments))
+	return true
+}
 
-	log.Printf("Менеджер ответил в тикет #%d через кнопку: %s", ticketID, replyText)
+// forwardClientMessage рассылает менеджерам уведомление о сообщении клиента и его вложения
+// (и копирует его в тему тикета в группе поддержки), запоминая отправленные копии для ответов через reply
+func forwardClientMessage(bot *tgbotapi.BotAPI, ticket *Ticket, messageID int, ids []int64, text string, attachments []Attachment) {
+	if supportGroupEnabled() {
+		mirrorClientMessageToTopic(bot, ticket, messageID)
+	}
+	for _, mid := range ids {
+		msg := tgbotapi.NewMessage(mid, text)
+		if sent, err := bot.Send(msg); err == nil {
+			linkMessageMirror(ticket, messageID, sent)
+		}
+		for _, sent := range sendAttachments(bot, mid, attachments, fmt.Sprintf("📎 Тикет #%d", ticket.ID)) {
+			linkMessageMirror(ticket, messageID, sent)
+		}
+	}
+	saveTickets()
 }
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// ticketTransfer — передача тикета другому менеджеру, которую оформляет менеджер
+type ticketTransfer struct {
+	TicketID     int
+	ToID         int64
+	Note         string
+	AwaitingNote bool // ждем текст заметки для коллеги
+}
+
+var transferState = make(map[int64]*ticketTransfer) // chatID менеджера -> оформляемая передача
+
+// handleTicketTransferCallback обрабатывает кнопки передачи тикета:
+//
+//	ticket_transfer_<ID> — выбор менеджера
+//	ticket_transfer_to_<ID>_<менеджер> — менеджер выбран, ждем заметку
+//	ticket_transfer_skip_<ID> — передать без заметки
+//	ticket_transfer_do_<ID>_<0|1> — передать (1 — сообщить клиенту)
+func handleTicketTransferCallback(bot *tgbotapi.BotAPI, chatID int64, data string) {
+	rest := strings.TrimPrefix(data, "ticket_transfer_")
+	action := ""
+	for _, a := range []string{"to_", "skip_", "do_"} {
+		if strings.HasPrefix(rest, a) {
+			action, rest = strings.TrimSuffix(a, "_"), strings.TrimPrefix(rest, a)
+			break
+		}
+	}
+	parts := strings.Split(rest, "_")
+	ticketID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Ошибка ID тикета"))
+		return
+	}
+
+	switch action {
+	case "":
+		showTransferManagers(bot, chatID, ticketID)
+	case "to":
+		if len(parts) != 2 {
+			return
+		}
+		toID, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return
+		}
+		startTransferNote(bot, chatID, ticketID, toID)
+	case "skip":
+		if t, ok := transferState[chatID]; ok && t.TicketID == ticketID {
+			t.AwaitingNote = false
+			t.Note = ""
+			confirmTransfer(bot, chatID)
+		}
+	case "do":
+		t, ok := transferState[chatID]
+		if !ok || t.TicketID != ticketID || len(parts) != 2 {
+			bot.Send(tgbotapi.NewMessage(chatID, "❌ Передача не найдена, начните заново"))
+			return
+		}
+		delete(transferState, chatID)
+		transferTicket(bot, chatID, t, parts[1] == "1")
+	}
+}
+
+// transferCandidates — менеджеры, которым можно передать тикет (все, кроме текущего ответственного), по имени
+func transferCandidates(ticket *Ticket) []int64 {
+	var ids []int64
+	for _, id := range getManagerIDs() {
+		if id != ticket.AssigneeID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return managerDisplayName(ids[i]) < managerDisplayName(ids[j])
+	})
+	return ids
+}
+
+// showTransferManagers показывает выбор менеджера для передачи тикета
+func showTransferManagers(bot *tgbotapi.BotAPI, chatID int64, ticketID int) {
+	ticket, exists := tickets[ticketID]
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	if ticket.Status != "open" {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет закрыт"))
+		return
+	}
+	candidates := transferCandidates(ticket)
+	if len(candidates) == 0 {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Нет других менеджеров для передачи"))
+		return
+	}
+
+	var keyboard [][]tgbotapi.InlineKeyboardButton
+	for _, id := range candidates {
+		title := managerDisplayName(id)
+		if id == chatID {
+			title += " (вы)"
+		}
+		if !isOnShift(id) {
+			title = "💤 " + title
+		}
+		keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData(title, fmt.Sprintf("ticket_transfer_to_%d_%d", ticketID, id)),
+		))
+	}
+	keyboard = append(keyboard, tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("ticket_view_%d", ticketID)),
+	))
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("↪️ Передача тикета #%d\n\nСейчас ответственный: %s\n\nКому передать тикет? (💤 — не на смене)",
+		ticketID, assigneeText(ticket)))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(keyboard...)
+	bot.Send(msg)
+}
+
+// startTransferNote запоминает выбранного менеджера и ждет заметку для него
+func startTransferNote(bot *tgbotapi.BotAPI, chatID int64, ticketID int, toID int64) {
+	if _, exists := tickets[ticketID]; !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	if !isManagerID(toID) {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Менеджер не найден"))
+		return
+	}
+	transferState[chatID] = &ticketTransfer{TicketID: ticketID, ToID: toID, AwaitingNote: true}
+
+	msg := tgbotapi.NewMessage(chatID, fmt.Sprintf("🗒 Заметка для %s\n\n"+
+		"Напишите, что важно знать по тикету #%d (что уже сделано, что ждет клиент). Клиенту заметка не отправляется.",
+		managerDisplayName(toID), ticketID))
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("➡️ Без заметки", fmt.Sprintf("ticket_transfer_skip_%d", ticketID)),
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("ticket_view_%d", ticketID)),
+		),
+	)
+	bot.Send(msg)
+}
+
+// handleTransferNoteInput принимает текст заметки для нового ответственного
+func handleTransferNoteInput(bot *tgbotapi.BotAPI, message *tgbotapi.Message) bool {
+	chatID := message.Chat.ID
+	t, ok := transferState[chatID]
+	if !ok || !t.AwaitingNote {
+		return false
+	}
+
+	text := strings.TrimSpace(message.Text)
+	if text == "/cancel" {
+		delete(transferState, chatID)
+		showTicketDetails(bot, chatID, t.TicketID)
+		return true
+	}
+	if text == "" {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Заметка может быть только текстом"))
+		return true
+	}
+	t.Note = text
+	t.AwaitingNote = false
+	confirmTransfer(bot, chatID)
+	return true
+}
+
+// confirmTransfer показывает итог передачи и спрашивает, сообщать ли клиенту
+func confirmTransfer(bot *tgbotapi.BotAPI, chatID int64) {
+	t := transferState[chatID]
+	text := fmt.Sprintf("↪️ Передать тикет #%d менеджеру %s?", t.TicketID, managerDisplayName(t.ToID))
+	if t.Note != "" {
+		text += "\n\n🗒 Заметка: " + t.Note
+	}
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("↪️ Передать", fmt.Sprintf("ticket_transfer_do_%d_0", t.TicketID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("↪️ Передать и сообщить клиенту", fmt.Sprintf("ticket_transfer_do_%d_1", t.TicketID)),
+		),
+		tgbotapi.NewInlineKeyboardRow(
+			tgbotapi.NewInlineKeyboardButtonData("❌ Отмена", fmt.Sprintf("ticket_view_%d", t.TicketID)),
+		),
+	)
+	bot.Send(msg)
+}
+
+// transferTicket назначает тикет другому менеджеру, сохраняет заметку и уведомляет участников
+func transferTicket(bot *tgbotapi.BotAPI, chatID int64, t *ticketTransfer, notifyClient bool) {
+	ticket, exists := tickets[t.TicketID]
+	if !exists {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет не найден"))
+		return
+	}
+	if ticket.Status != "open" {
+		bot.Send(tgbotapi.NewMessage(chatID, "❌ Тикет закрыт"))
+		return
+	}
+	if ticket.AssigneeID == t.ToID {
+		bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("ℹ️ Тикет #%d уже у %s", ticket.ID, managerDisplayName(t.ToID))))
+		return
+	}
+
+	fromID := ticket.AssigneeID
+	setTicketAssignee(ticket, t.ToID)
+	eventText := fmt.Sprintf("%s передал тикет %s", managerDisplayName(chatID), managerDisplayName(t.ToID))
+	if fromID != 0 && fromID != chatID {
+		eventText += fmt.Sprintf(" (был у %s)", managerDisplayName(fromID))
+	}
+	if t.Note != "" {
+		eventText += ": " + t.Note
+		addTicketNote(ticket, chatID, "↪️ При передаче: "+t.Note)
+	}
+	logTicketEvent(ticket, "transferred", chatID, eventText)
+	saveTickets()
+	log.Printf("Тикет #%d передан менеджером %d менеджеру %d", ticket.ID, chatID, t.ToID)
+
+	// Новый ответственный получает заметку и карточку тикета с последними сообщениями
+	if t.ToID != chatID {
+		handoff := fmt.Sprintf("↪️ %s передал вам тикет #%d", managerDisplayName(chatID), ticket.ID)
+		if t.Note != "" {
+			handoff += "\n\n🗒 Заметка: " + t.Note
+		}
+		bot.Send(tgbotapi.NewMessage(t.ToID, handoff))
+		showTicketDetails(bot, t.ToID, ticket.ID)
+	}
+	if fromID != 0 && fromID != chatID && fromID != t.ToID {
+		bot.Send(tgbotapi.NewMessage(fromID, fmt.Sprintf("ℹ️ %s передал тикет #%d менеджеру %s",
+			managerDisplayName(chatID), ticket.ID, managerDisplayName(t.ToID))))
+	}
+	if ticket.TopicID != 0 {
+		sendTopicMessage(bot, ticket, "↪️ "+eventText)
+	}
+
+	if notifyClient {
+		text := fmt.Sprintf("👨‍💼 Вашим обращением (тикет #%d) теперь занимается другой менеджер. Продолжайте писать в этот чат — история переписки сохранена.", ticket.ID)
+		if name, ok := managerNames[t.ToID]; ok && name != "" {
+			text = fmt.Sprintf("👨‍💼 Вашим обращением (тикет #%d) теперь занимается менеджер %s. Продолжайте писать в этот чат — история переписки сохранена.", ticket.ID, name)
+		}
+		sendToClient(bot, ticket, tgbotapi.NewMessage(ticket.UserID, text), 0)
+	}
+
+	bot.Send(tgbotapi.NewMessage(chatID, fmt.Sprintf("✅ Тикет #%d передан менеджеру %s", ticket.ID, managerDisplayName(t.ToID))))
+}